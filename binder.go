@@ -18,6 +18,8 @@ var SchemaDecoder = func() *schema.Decoder {
 	d := schema.NewDecoder()
 	d.SetAliasTag("form") // 优先读取 form tag
 	d.IgnoreUnknownKeys(true)
+	// []byte 按字符串解码，而不是逐个元素解析为 uint8
+	d.RegisterConverter([]byte(nil), func(s string) reflect.Value { return reflect.ValueOf([]byte(s)) })
 	return d
 }()
 
@@ -40,6 +42,12 @@ type structMeta struct {
 
 	// formKeys collecting for No-Vary-Search
 	formKeys []string
+
+	// queryFields: 显式标记了 `form` tag 的非 path 字段，用于 URLFor 生成查询参数
+	queryFields []queryFieldInfo
+
+	// identityFields: 标记了 `identity` tag 的字段，由 IdentityBinder 注入
+	identityFields [][]int
 }
 
 type pathFieldInfo struct {
@@ -48,6 +56,11 @@ type pathFieldInfo struct {
 	schemaKey string // 传给 SchemaDecoder 的 key (即 form tag 或 json tag)
 }
 
+type queryFieldInfo struct {
+	fieldIdx []int
+	key      string
+}
+
 type fileFieldInfo struct {
 	fieldIdx []int  // 字段索引路径
 	formKey  string // 表单中的文件名 key
@@ -104,6 +117,12 @@ func getStructMeta(t reflect.Type) *structMeta {
 					fieldType = fieldType.Elem()
 				}
 
+				// 身份字段 (包括嵌入的身份类型) 只由 IdentityBinder 填充，不参与其他映射
+				if _, ok := field.Tag.Lookup("identity"); ok && field.IsExported() {
+					meta.identityFields = append(meta.identityFields, idxPath)
+					continue
+				}
+
 				// 处理匿名嵌套结构体
 				if field.Anonymous && fieldType.Kind() == reflect.Struct {
 					walk(fieldType, idxPath)
@@ -155,6 +174,7 @@ func getStructMeta(t reflect.Type) *structMeta {
 				}
 
 				// B. 解析文件上传元数据
+				isFile := true
 				if field.Type == reflect.TypeOf((*multipart.FileHeader)(nil)) {
 					meta.fileFields = append(meta.fileFields, fileFieldInfo{
 						fieldIdx: idxPath,
@@ -167,6 +187,13 @@ func getStructMeta(t reflect.Type) *structMeta {
 						formKey:  mapKey,
 						isSlice:  true,
 					})
+				} else {
+					isFile = false
+				}
+
+				// C. 显式 form tag 的字段可作为查询参数反向生成 URL
+				if pathKey == "" && !isFile && field.Tag.Get("form") != "" {
+					meta.queryFields = append(meta.queryFields, queryFieldInfo{fieldIdx: idxPath, key: mapKey})
 				}
 			}
 		}
@@ -198,6 +225,7 @@ var Binders = []Binder{
 	&QueryBinder{},
 	&JsonBinder{DisallowUnknownFields: true},
 	&FormBinder{MaxMemory: DefaultMultipartMemory},
+	&IdentityBinder{}, // 必须位于最后，覆盖其他 Binder 可能写入的值
 }
 
 // Bind 自动选择绑定器处理请求
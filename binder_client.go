@@ -1,14 +1,94 @@
 package httpx
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
 	"net/http"
+	"net/url"
 	"reflect"
 	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
 )
 
-// ClientAuthBinder 处理 HTTP Basic Auth
-// 专门用于 OIDC/OAuth2 场景，自动提取 client_id 和 client_secret
-type ClientAuthBinder struct{}
+// ClientAuthBinder 处理 OAuth2 Token 端点的客户端认证 (RFC 6749 §2.3, OIDC Core §9)：
+//   - client_secret_basic: Authorization: Basic (参见 DecodeBasicCredentials)
+//   - client_secret_post: 表单中的 client_id/client_secret
+//   - private_key_jwt / client_secret_jwt: 表单中的 client_assertion (RFC 7523)
+//
+// 请求结构体嵌入 ClientCredentials 即可获得全部字段以及实际使用的认证方式；
+// 仅声明了 client_id/client_secret 字段 (form/json tag) 的旧结构体仍然只处理 Basic Auth。
+// 同时使用多种认证方式时返回 invalid_request，错误均为 *OAuthError。
+//
+// 该 Binder 不在默认链中，需要通过 WithBinders 显式启用：
+//
+//	httpx.WithBinders(&httpx.FormBinder{}, &httpx.ClientAuthBinder{Assertion: &httpx.ClientAssertionOptions{...}})
+type ClientAuthBinder struct {
+	// Assertion 不为 nil 时验证 client_assertion 的签名与声明；
+	// 为 nil 时只填充字段 (AuthMethod 为 ClientAuthUnverifiedJWT)，由业务函数自行验证。
+	Assertion *ClientAssertionOptions
+
+	// DecodeBasicCredentials 为 true 时按 RFC 6749 §2.3.1 对 Basic Auth 中的 client_id/client_secret
+	// 做 form-urlencoded 解码。默认直接使用原始值，与早期版本保持一致；
+	// 只有客户端确实按规范编码凭证时才应开启，否则含有 "%" 或 "+" 的凭证会被改变或拒绝。
+	DecodeBasicCredentials bool
+}
+
+// ClientAssertionOptions 配置 RFC 7523 client_assertion 的验证。
+type ClientAssertionOptions struct {
+	// Keys 返回客户端注册的密钥 (private_key_jwt 为公钥/JWKS，client_secret_jwt 为共享密钥)。
+	// 未知客户端应返回 ErrTokenKeyNotFound，其他错误视为服务端错误。
+	Keys func(ctx context.Context, clientID string) (KeySet, error)
+	// Audience 授权服务器的标识，通常为 Token 端点 URL 或 Issuer。必填。
+	Audience []string
+	// Algorithms 允许的签名算法，为空时允许 KeySet 能验证的任意算法。
+	Algorithms []string
+	// ClockSkew 允许的时钟偏差。
+	ClockSkew time.Duration
+	// Nonces 不为 nil 时按 jti 拒绝重放的断言。
+	Nonces NonceCache
+}
+
+// ClientAssertionTypeJWTBearer 是 RFC 7523 定义的 client_assertion_type
+const ClientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// 客户端认证方式 (OIDC Core §9 token_endpoint_auth_method)
+const (
+	ClientAuthNone          = "none"
+	ClientAuthSecretBasic   = "client_secret_basic"
+	ClientAuthSecretPost    = "client_secret_post"
+	ClientAuthPrivateKeyJWT = "private_key_jwt"
+	ClientAuthSecretJWT     = "client_secret_jwt"
+
+	// ClientAuthUnverifiedJWT 表示请求携带了 client_assertion，但 ClientAuthBinder 未配置 Assertion，
+	// 断言尚未经过验证。它不是 OIDC 定义的认证方式，业务函数验证断言后应自行更新 AuthMethod。
+	ClientAuthUnverifiedJWT = "unverified_jwt"
+)
+
+// ClientCredentials 是客户端认证的结果，嵌入到 Token 端点的请求结构体中使用：
+//
+//	type TokenReq struct {
+//		httpx.ClientCredentials
+//		GrantType string `form:"grant_type" validate:"required"`
+//		Code      string `form:"code"`
+//	}
+type ClientCredentials struct {
+	ClientID            string `form:"client_id" json:"client_id,omitempty"`
+	ClientSecret        string `form:"client_secret" json:"client_secret,omitempty"`
+	ClientAssertionType string `form:"client_assertion_type" json:"client_assertion_type,omitempty"`
+	ClientAssertion     string `form:"client_assertion" json:"client_assertion,omitempty"`
+	// AuthMethod 实际使用的认证方式 (ClientAuth*)，由 ClientAuthBinder 设置，不可由客户端提交。
+	// 未配置 Assertion 时断言没有被验证，报告为 ClientAuthUnverifiedJWT。
+	AuthMethod string `form:"-" json:"-"`
+}
+
+func (c *ClientCredentials) clientCredentials() *ClientCredentials { return c }
+
+type clientCredentialsHolder interface {
+	clientCredentials() *ClientCredentials
+}
 
 func (b *ClientAuthBinder) Name() string     { return "client_auth" }
 func (b *ClientAuthBinder) Type() BinderType { return BinderMeta } // 属于元数据类 (Header)
@@ -16,44 +96,227 @@ func (b *ClientAuthBinder) Type() BinderType { return BinderMeta } // 属于元
 func (b *ClientAuthBinder) Match(r *http.Request) bool {
 	// 快速检查 Header 是否存在，避免无意义的解析
 	auth := r.Header.Get("Authorization")
-	return len(auth) >= 6 && strings.EqualFold(auth[:6], "Basic ")
+	if len(auth) >= 6 && strings.EqualFold(auth[:6], "Basic ") {
+		return true
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		return false
+	}
+	// 只处理真正携带了客户端凭证的表单，普通表单交给其他 Binder
+	// ParseForm 是幂等的，解析失败时由 FormBinder 报告错误
+	if err := r.ParseForm(); err != nil {
+		return false
+	}
+	return r.PostForm.Has("client_id") || r.PostForm.Has("client_assertion")
 }
 
 func (b *ClientAuthBinder) Bind(r *http.Request, v any) error {
-	// 1. 解析 Basic Auth
-	// 标准库 BasicAuth 已经处理了 base64 解码
-	uid, pwd, ok := r.BasicAuth()
-	if !ok {
+	// 1. 收集各种方式提交的凭证
+	var creds ClientCredentials
+	uid, pwd, basic := r.BasicAuth()
+	if basic {
+		creds.ClientID, creds.ClientSecret = uid, pwd
+		if b.DecodeBasicCredentials {
+			var err1, err2 error
+			creds.ClientID, err1 = url.QueryUnescape(uid)
+			creds.ClientSecret, err2 = url.QueryUnescape(pwd)
+			if err1 != nil || err2 != nil {
+				return ErrOAuthInvalidRequest.WithDescription("malformed basic credentials")
+			}
+		}
+		creds.AuthMethod = ClientAuthSecretBasic
+	}
+
+	var form url.Values
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		// ParseForm 是幂等的，可以与 FormBinder 共存
+		if err := r.ParseForm(); err != nil {
+			return ErrOAuthInvalidRequest.WithDescription("malformed form body")
+		}
+		form = r.PostForm
+	}
+
+	if err := b.collectForm(&creds, form); err != nil {
+		return err
+	}
+
+	// 2. 验证断言
+	if creds.AuthMethod == ClientAuthUnverifiedJWT && b.Assertion != nil {
+		method, err := b.Assertion.verify(r.Context(), &creds)
+		if err != nil {
+			return err
+		}
+		creds.AuthMethod = method
+	}
+
+	// 3. 填充结构体
+	if holder, ok := v.(clientCredentialsHolder); ok {
+		*holder.clientCredentials() = creds
 		return nil
 	}
+	if basic {
+		b.fillLegacy(v, creds.ClientID, creds.ClientSecret)
+	}
+	return nil
+}
 
-	// 2. 获取结构体值与元数据
+// collectForm 合并表单中的凭证，并拒绝同时使用多种认证方式的请求 (RFC 6749 §2.3)。
+func (b *ClientAuthBinder) collectForm(creds *ClientCredentials, form url.Values) error {
+	if form == nil {
+		return nil
+	}
+	for _, key := range [...]string{"client_id", "client_secret", "client_assertion_type", "client_assertion"} {
+		if len(form[key]) > 1 {
+			return ErrOAuthInvalidRequest.WithDescription("duplicate parameter " + key)
+		}
+	}
+
+	formID := form.Get("client_id")
+	if creds.AuthMethod == ClientAuthSecretBasic {
+		if formID != "" && formID != creds.ClientID {
+			return ErrOAuthInvalidRequest.WithDescription("client_id does not match authorization header")
+		}
+		if form.Has("client_secret") || form.Has("client_assertion") {
+			return ErrOAuthInvalidRequest.WithDescription("multiple client authentication methods")
+		}
+		return nil
+	}
+
+	creds.ClientID = formID
+	secret, assertion := form.Get("client_secret"), form.Get("client_assertion")
+	switch {
+	case secret != "" && assertion != "":
+		return ErrOAuthInvalidRequest.WithDescription("multiple client authentication methods")
+	case assertion != "":
+		if form.Get("client_assertion_type") != ClientAssertionTypeJWTBearer {
+			return ErrOAuthInvalidClient.WithDescription("unsupported client_assertion_type")
+		}
+		creds.ClientAssertionType = ClientAssertionTypeJWTBearer
+		creds.ClientAssertion = assertion
+		// 具体是 private_key_jwt 还是 client_secret_jwt 由验证时的算法决定
+		creds.AuthMethod = ClientAuthUnverifiedJWT
+	case secret != "":
+		creds.ClientSecret = secret
+		creds.AuthMethod = ClientAuthSecretPost
+	case formID != "":
+		creds.AuthMethod = ClientAuthNone
+	}
+	return nil
+}
+
+// fillLegacy 填充声明了 client_id/client_secret tag 的字段 (如果字段为空)
+func (b *ClientAuthBinder) fillLegacy(v any, id, secret string) {
 	val := reflect.ValueOf(v)
 	if val.Kind() == reflect.Ptr {
 		val = val.Elem()
 	}
 	if val.Kind() != reflect.Struct {
-		return nil
+		return
 	}
 
 	// O(1) 获取缓存
 	meta := getStructMeta(val.Type())
-
-	// 3. 填充 ClientID (如果结构体中对应字段为空)
-	if meta.clientIDIdx != nil && uid != "" {
-		field := getFieldByIndex(val, meta.clientIDIdx)
+	for _, f := range [...]struct {
+		idx []int
+		val string
+	}{{meta.clientIDIdx, id}, {meta.clientSecretIdx, secret}} {
+		if f.idx == nil || f.val == "" {
+			continue
+		}
+		field := getFieldByIndex(val, f.idx)
 		if field.IsValid() && field.CanSet() && field.String() == "" {
-			field.SetString(uid)
+			field.SetString(f.val)
 		}
 	}
+}
 
-	// 4. 填充 ClientSecret (如果结构体中对应字段为空)
-	if meta.clientSecretIdx != nil && pwd != "" {
-		field := getFieldByIndex(val, meta.clientSecretIdx)
-		if field.IsValid() && field.CanSet() && field.String() == "" {
-			field.SetString(pwd)
+// InvalidClient 返回 invalid_client 错误，供业务函数在校验密钥失败时使用。
+// 客户端通过 Basic Auth 认证时附带 WWW-Authenticate: Basic (RFC 6749 §5.2)。
+func (c *ClientCredentials) InvalidClient(desc string) *OAuthError {
+	err := ErrOAuthInvalidClient.WithDescription(desc)
+	if c.AuthMethod == ClientAuthSecretBasic {
+		err.Challenge = `Basic realm="oauth"`
+	}
+	return err
+}
+
+// verify 验证 client_assertion (RFC 7523 §3)，返回实际的认证方式。
+// 成功后 creds.ClientID 被设置为断言的 sub。
+func (o *ClientAssertionOptions) verify(ctx context.Context, creds *ClientCredentials) (string, error) {
+	invalid := func(desc string) error { return ErrOAuthInvalidClient.WithDescription(desc) }
+	if o.Keys == nil || len(o.Audience) == 0 {
+		// 没有 Audience 的断言可以被其他授权服务器重放，视为配置错误
+		return "", ErrOAuthServerError
+	}
+
+	// 1. 未验证地读取 iss/sub 以确定客户端，随后用该客户端的密钥验证签名
+	parts := strings.Split(creds.ClientAssertion, ".")
+	if len(parts) != 3 {
+		return "", invalid("malformed client_assertion")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", invalid("malformed client_assertion")
+	}
+	var claims RegisteredClaims
+	if err := sonic.Unmarshal(payload, &claims); err != nil {
+		return "", invalid("malformed client_assertion")
+	}
+	clientID := creds.ClientID
+	if clientID == "" {
+		clientID = claims.Subject
+	}
+	if clientID == "" || claims.Issuer != clientID || claims.Subject != clientID {
+		return "", invalid("client_assertion iss and sub must be the client_id")
+	}
+
+	// 2. 查找客户端密钥
+	keys, err := o.Keys(ctx, clientID)
+	if errors.Is(err, ErrTokenKeyNotFound) {
+		return "", invalid("unknown client")
+	}
+	if err != nil {
+		return "", ErrOAuthServerError
+	}
+
+	// 3. 验证签名与注册声明
+	verifier := newJWTVerifier(JWTOptions{
+		Keys:       keys,
+		Algorithms: o.Algorithms,
+		Issuer:     clientID,
+		Audience:   o.Audience,
+		ClockSkew:  o.ClockSkew,
+		// exp 由下方单独检查，以返回断言专用的错误描述
+		AllowMissingExpiry: true,
+	})
+	if err := verifier.verify(ctx, creds.ClientAssertion, &claims); err != nil {
+		if isTokenError(err) {
+			return "", invalid("client_assertion " + err.Error())
 		}
+		return "", ErrOAuthServerError
+	}
+	if claims.ExpiresAt == 0 {
+		return "", invalid("client_assertion must contain exp")
 	}
 
-	return nil
+	// 4. 防重放
+	if o.Nonces != nil {
+		if claims.ID == "" {
+			return "", invalid("client_assertion must contain jti")
+		}
+		fresh, err := o.Nonces.Use(ctx, clientID+":"+claims.ID, claims.ExpiresAt.Time().Add(o.ClockSkew))
+		if err != nil {
+			return "", ErrOAuthServerError
+		}
+		if !fresh {
+			return "", invalid("client_assertion has been used")
+		}
+	}
+
+	creds.ClientID = clientID
+	var h jwtHeader
+	if header, err := base64.RawURLEncoding.DecodeString(parts[0]); err == nil && sonic.Unmarshal(header, &h) == nil && strings.HasPrefix(h.Alg, "HS") {
+		return ClientAuthSecretJWT, nil
+	}
+	return ClientAuthPrivateKeyJWT, nil
 }
@@ -0,0 +1,201 @@
+package httpx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func clientAuthRequest(form url.Values, user, pass string) *http.Request {
+	r := httptest.NewRequest("POST", "/token", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if user != "" {
+		r.SetBasicAuth(user, pass)
+	}
+	return r
+}
+
+func TestClientAuthBinder_Secret(t *testing.T) {
+	b := &ClientAuthBinder{}
+	tests := []struct {
+		name       string
+		form       url.Values
+		user, pass string
+		want       ClientCredentials
+		wantErr    *OAuthError
+		noMatch    bool
+	}{
+		{
+			name: "Basic",
+			user: "app", pass: "50%+off",
+			want: ClientCredentials{ClientID: "app", ClientSecret: "50%+off", AuthMethod: ClientAuthSecretBasic},
+		},
+		{
+			name: "BasicWithMatchingFormID",
+			form: url.Values{"client_id": {"app"}}, user: "app", pass: "s",
+			want: ClientCredentials{ClientID: "app", ClientSecret: "s", AuthMethod: ClientAuthSecretBasic},
+		},
+		{
+			name: "Post",
+			form: url.Values{"client_id": {"app"}, "client_secret": {"s"}},
+			want: ClientCredentials{ClientID: "app", ClientSecret: "s", AuthMethod: ClientAuthSecretPost},
+		},
+		{
+			name: "Public",
+			form: url.Values{"client_id": {"spa"}},
+			want: ClientCredentials{ClientID: "spa", AuthMethod: ClientAuthNone},
+		},
+		{name: "ForgedMethod", form: url.Values{"AuthMethod": {"private_key_jwt"}}, noMatch: true},
+		{name: "IDMismatch", form: url.Values{"client_id": {"other"}}, user: "app", pass: "s", wantErr: ErrOAuthInvalidRequest},
+		{name: "BasicAndPost", form: url.Values{"client_secret": {"s"}}, user: "app", pass: "s", wantErr: ErrOAuthInvalidRequest},
+		{name: "SecretAndAssertion", form: url.Values{"client_secret": {"s"}, "client_assertion": {"a.b.c"}, "client_assertion_type": {ClientAssertionTypeJWTBearer}}, wantErr: ErrOAuthInvalidRequest},
+		{name: "Duplicate", form: url.Values{"client_id": {"a", "b"}}, wantErr: ErrOAuthInvalidRequest},
+		{name: "AssertionType", form: url.Values{"client_assertion": {"a.b.c"}, "client_assertion_type": {"saml"}}, wantErr: ErrOAuthInvalidClient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := clientAuthRequest(tt.form, tt.user, tt.pass)
+			require.Equal(t, !tt.noMatch, b.Match(r))
+			var req tokenReq
+			err := b.Bind(r, &req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, req.ClientCredentials)
+		})
+	}
+
+	assert.False(t, b.Match(httptest.NewRequest("GET", "/", nil)))
+	// 没有携带客户端凭证的普通表单不会被匹配
+	assert.False(t, b.Match(clientAuthRequest(url.Values{"name": {"alice"}}, "", "")))
+}
+
+func TestClientAuthBinder_DecodeBasicCredentials(t *testing.T) {
+	b := &ClientAuthBinder{DecodeBasicCredentials: true}
+	r := clientAuthRequest(nil, url.QueryEscape("my app"), url.QueryEscape("p@ss:word"))
+	var req tokenReq
+	require.NoError(t, b.Bind(r, &req))
+	assert.Equal(t, "my app", req.ClientID)
+	assert.Equal(t, "p@ss:word", req.ClientSecret)
+
+	// 按 RFC 6749 §2.3.1 解码时，未编码的 "%" 被拒绝
+	err := b.Bind(clientAuthRequest(nil, "app", "%zz"), &req)
+	assert.ErrorIs(t, err, ErrOAuthInvalidRequest)
+}
+
+func TestClientAuthBinder_Legacy(t *testing.T) {
+	type legacyReq struct {
+		ClientID     string `form:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	r := httptest.NewRequest("POST", "/token", nil)
+	r.SetBasicAuth("app", "s")
+	var req legacyReq
+	require.NoError(t, (&ClientAuthBinder{}).Bind(r, &req))
+	assert.Equal(t, legacyReq{ClientID: "app", ClientSecret: "s"}, req)
+}
+
+func TestClientAuthBinder_Assertion(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	shared := []byte("0123456789abcdef0123456789abcdef")
+
+	nonces := NewMemoryNonceCache()
+	b := &ClientAuthBinder{Assertion: &ClientAssertionOptions{
+		Keys: func(ctx context.Context, clientID string) (KeySet, error) {
+			switch clientID {
+			case "app":
+				return StaticKeys{"k1": &priv.PublicKey}, nil
+			case "legacy":
+				return StaticKeys{"": shared}, nil
+			}
+			return nil, ErrTokenKeyNotFound
+		},
+		Audience: []string{"https://as.example.com/token"},
+		Nonces:   nonces,
+	}}
+
+	claims := func(client, jti string) RegisteredClaims {
+		return RegisteredClaims{
+			Issuer:    client,
+			Subject:   client,
+			Audience:  Audience{"https://as.example.com/token"},
+			ExpiresAt: NumericDate(time.Now().Add(time.Minute).Unix()),
+			ID:        jti,
+		}
+	}
+	bind := func(form url.Values) (*tokenReq, error) {
+		form.Set("client_assertion_type", ClientAssertionTypeJWTBearer)
+		var req tokenReq
+		return &req, b.Bind(clientAuthRequest(form, "", ""), &req)
+	}
+
+	t.Run("PrivateKeyJWT", func(t *testing.T) {
+		assertion := signTestJWT(t, "ES256", "k1", priv, claims("app", "j1"))
+		req, err := bind(url.Values{"client_assertion": {assertion}})
+		require.NoError(t, err)
+		assert.Equal(t, "app", req.ClientID)
+		assert.Equal(t, ClientAuthPrivateKeyJWT, req.AuthMethod)
+
+		_, err = bind(url.Values{"client_assertion": {assertion}})
+		assert.ErrorIs(t, err, ErrOAuthInvalidClient, "replayed jti")
+	})
+
+	t.Run("ClientSecretJWT", func(t *testing.T) {
+		assertion := signTestJWT(t, "HS256", "", shared, claims("legacy", "j2"))
+		req, err := bind(url.Values{"client_id": {"legacy"}, "client_assertion": {assertion}})
+		require.NoError(t, err)
+		assert.Equal(t, ClientAuthSecretJWT, req.AuthMethod)
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		wrongAud := claims("app", "j3")
+		wrongAud.Audience = Audience{"https://other.example.com"}
+		noExp := claims("app", "j4")
+		noExp.ExpiresAt = 0
+		spoofed := claims("app", "j5")
+		spoofed.Issuer = "attacker"
+
+		for name, form := range map[string]url.Values{
+			"Audience":  {"client_assertion": {signTestJWT(t, "ES256", "k1", priv, wrongAud)}},
+			"NoExp":     {"client_assertion": {signTestJWT(t, "ES256", "k1", priv, noExp)}},
+			"Issuer":    {"client_assertion": {signTestJWT(t, "ES256", "k1", priv, spoofed)}},
+			"ClientID":  {"client_id": {"legacy"}, "client_assertion": {signTestJWT(t, "ES256", "k1", priv, claims("app", "j6"))}},
+			"Unknown":   {"client_assertion": {signTestJWT(t, "HS256", "", shared, claims("nobody", "j7"))}},
+			"Signature": {"client_assertion": {signTestJWT(t, "HS256", "", []byte("wrong-secret-wrong-secret-wrong!"), claims("legacy", "j8"))}},
+			"Malformed": {"client_assertion": {"not-a-jwt"}},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := bind(form)
+				var oauthErr *OAuthError
+				require.ErrorAs(t, err, &oauthErr)
+				assert.Equal(t, "invalid_client", oauthErr.Code)
+				assert.Equal(t, http.StatusUnauthorized, oauthErr.HTTPStatus())
+				if name == "NoExp" {
+					assert.Equal(t, "client_assertion must contain exp", oauthErr.Description)
+				}
+			})
+		}
+	})
+
+	t.Run("Unverified", func(t *testing.T) {
+		var req tokenReq
+		form := url.Values{"client_assertion_type": {ClientAssertionTypeJWTBearer}, "client_assertion": {"a.b.c"}}
+		require.NoError(t, (&ClientAuthBinder{}).Bind(clientAuthRequest(form, "", ""), &req))
+		assert.Equal(t, "a.b.c", req.ClientAssertion)
+		// 未经验证的断言不能被报告为 private_key_jwt
+		assert.Equal(t, ClientAuthUnverifiedJWT, req.AuthMethod)
+	})
+}
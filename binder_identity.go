@@ -0,0 +1,60 @@
+package httpx
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// IdentityBinder 将 Auth 中间件注入的身份 (GetIdentity) 赋值给标记了 `identity` tag 的字段，
+// 使业务函数无需接触 Context Key：
+//
+//	type UpdateProfileReq struct {
+//		User *MyClaims `identity:"" json:"-"`
+//		Bio  string    `json:"bio"`
+//	}
+//
+// 字段类型可以是身份本身的类型、它实现的接口，或者身份为指针时其指向的值类型。
+// 未认证或类型不符时字段被置为零值，因此客户端无法通过 Query/Body 伪造身份。
+// 该 Binder 位于默认链的最后；使用 WithBinders 自定义链时也应将其放在最后。
+type IdentityBinder struct{}
+
+func (b *IdentityBinder) Name() string     { return "identity" }
+func (b *IdentityBinder) Type() BinderType { return BinderMeta }
+func (b *IdentityBinder) Match(r *http.Request) bool {
+	return true // 即使没有身份也要执行，以清除伪造的值
+}
+
+func (b *IdentityBinder) Bind(r *http.Request, v any) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+
+	// O(1) 获取缓存
+	meta := getStructMeta(val.Type())
+	if len(meta.identityFields) == 0 {
+		return nil
+	}
+
+	identity := reflect.ValueOf(GetIdentity(r.Context()))
+	for _, idx := range meta.identityFields {
+		field := getFieldByIndex(val, idx)
+		if !field.IsValid() || !field.CanSet() {
+			continue
+		}
+		switch {
+		case !identity.IsValid():
+			field.SetZero()
+		case identity.Type().AssignableTo(field.Type()):
+			field.Set(identity)
+		case identity.Kind() == reflect.Ptr && !identity.IsNil() && identity.Elem().Type().AssignableTo(field.Type()):
+			field.Set(identity.Elem())
+		default:
+			field.SetZero()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type identityClaims struct {
+	Subject string `json:"sub"`
+}
+
+type identityReq struct {
+	User    *identityClaims `identity:""`
+	Caller  any             `identity:""`
+	Scopes  ScopeHolder     `identity:"" json:"-"`
+	Profile identityClaims  `identity:"" json:"-"`
+	Bio     string          `json:"bio"`
+}
+
+// EmbeddedClaims 用于测试嵌入的身份类型 (嵌入字段必须导出才能被赋值)
+type EmbeddedClaims struct {
+	Subject string `json:"sub"`
+}
+
+type embeddedIdentityReq struct {
+	*EmbeddedClaims `identity:""`
+	Name            string `form:"name"`
+}
+
+func TestIdentityBinder(t *testing.T) {
+	bind := func(identity any, body string) (*identityReq, error) {
+		r := httptest.NewRequest("POST", "/?User.Subject=forged", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if identity != nil {
+			r = r.WithContext(context.WithValue(r.Context(), IdentityKey{}, identity))
+		}
+		var req identityReq
+		return &req, Bind(r, &req)
+	}
+
+	t.Run("Claims", func(t *testing.T) {
+		claims := &identityClaims{Subject: "user-1"}
+		req, err := bind(claims, `{"bio":"hi"}`)
+		require.NoError(t, err)
+		assert.Same(t, claims, req.User)
+		assert.Same(t, claims, req.Caller)
+		assert.Equal(t, identityClaims{Subject: "user-1"}, req.Profile)
+		assert.Nil(t, req.Scopes, "claims do not implement ScopeHolder")
+		assert.Equal(t, "hi", req.Bio)
+	})
+
+	t.Run("Interface", func(t *testing.T) {
+		key := &APIKey{Owner: "partner"}
+		req, err := bind(key, `{}`)
+		require.NoError(t, err)
+		assert.Same(t, key, req.Scopes)
+		assert.Nil(t, req.User)
+	})
+
+	t.Run("AnonymousCannotForge", func(t *testing.T) {
+		req, err := bind(nil, `{"User":{"sub":"admin"},"Caller":"admin"}`)
+		require.NoError(t, err)
+		assert.Nil(t, req.User)
+		assert.Nil(t, req.Caller)
+	})
+
+	t.Run("Embedded", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/?name=bob&sub=forged", nil)
+		r = r.WithContext(context.WithValue(r.Context(), IdentityKey{}, &EmbeddedClaims{Subject: "user-2"}))
+		var req embeddedIdentityReq
+		require.NoError(t, Bind(r, &req))
+		assert.Equal(t, "user-2", req.Subject)
+		assert.Equal(t, "bob", req.Name)
+	})
+
+	t.Run("Handler", func(t *testing.T) {
+		h := NewHandler(func(ctx context.Context, req *identityReq) (*TestRes, error) {
+			if req.User == nil {
+				return nil, ErrUnauthorized
+			}
+			return &TestRes{ID: req.User.Subject}, nil
+		})
+		r := httptest.NewRequest("POST", "/", strings.NewReader(`{"bio":"x"}`))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), IdentityKey{}, &identityClaims{Subject: "user-3"})))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"id":"user-3"`)
+	})
+}
@@ -0,0 +1,276 @@
+// Package client 提供调用 httpx 服务的类型化客户端。
+//
+// 它复用服务端的结构体标签 (path, form, json, header) 构造请求，
+// 解码 httpx.Response[T] 信封，并将错误响应还原为 *httpx.HttpError，
+// 因此调用方可以像在服务端一样使用 errors.As 判断错误:
+//
+//	getUser := client.NewClient[GetUserReq, *User](http.MethodGet, "/users/{id}",
+//		client.WithBaseURL("http://user-svc"))
+//
+//	user, err := getUser.Call(ctx, &GetUserReq{ID: 42})
+//	var httpErr *httpx.HttpError
+//	if errors.As(err, &httpErr) && httpErr.BizCode == "USER_NOT_FOUND" { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/oy3o/httpx"
+	"github.com/oy3o/httpx/internal/urlenc"
+)
+
+// DefaultMaxResponseSize 是响应体的默认读取上限 (4MB)
+const DefaultMaxResponseSize = 4 << 20
+
+// ResponseTooLargeError 表示响应体超过了读取上限 (参见 WithMaxResponseSize)
+type ResponseTooLargeError struct {
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("client: response body exceeds %d bytes", e.Limit)
+}
+
+// Option 配置 Client
+type Option func(*options)
+
+type options struct {
+	baseURL         string
+	httpClient      *http.Client
+	header          http.Header
+	noEnvelope      bool
+	maxResponseSize int64
+}
+
+// WithBaseURL 设置服务地址，如 "http://user-svc:8080"
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithHTTPClient 设置底层 *http.Client，默认为 http.DefaultClient
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = c
+	}
+}
+
+// WithHeader 为每个请求添加固定 Header (如服务间调用的认证头)
+func WithHeader(key, value string) Option {
+	return func(o *options) {
+		o.header.Add(key, value)
+	}
+}
+
+// WithMaxResponseSize 设置响应体的读取上限，默认为 DefaultMaxResponseSize，<= 0 表示不限制。
+// 超出上限时 Call 返回 *ResponseTooLargeError，防止异常的上游耗尽内存。
+func WithMaxResponseSize(n int64) Option {
+	return func(o *options) {
+		o.maxResponseSize = n
+	}
+}
+
+// NoEnvelope 对应服务端的 httpx.NoEnvelope()，成功响应直接解码为 Res
+func NoEnvelope() Option {
+	return func(o *options) {
+		o.noEnvelope = true
+	}
+}
+
+// Client 是一个绑定到单个路由的类型化客户端，可被多个 goroutine 并发使用。
+type Client[Req any, Res any] struct {
+	method  string
+	pattern string
+	opts    options
+}
+
+// NewClient 为路由 method + pattern 创建类型化客户端。
+// pattern 与服务端注册时的路径部分一致，如 "/users/{id}" 或 "/files/{path...}"。
+func NewClient[Req any, Res any](method, pattern string, opts ...Option) *Client[Req, Res] {
+	o := options{
+		httpClient:      http.DefaultClient,
+		header:          make(http.Header),
+		maxResponseSize: DefaultMaxResponseSize,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Client[Req, Res]{
+		method:  method,
+		pattern: pattern,
+		opts:    o,
+	}
+}
+
+// Call 发送请求并解码响应。
+// 服务端返回非 2xx 时，error 为 *httpx.HttpError，其 HttpCode/BizCode/Msg 取自响应。
+func (c *Client[Req, Res]) Call(ctx context.Context, req *Req) (Res, error) {
+	var zero Res
+
+	httpReq, err := c.NewRequest(ctx, req)
+	if err != nil {
+		return zero, err
+	}
+
+	resp, err := c.opts.httpClient.Do(httpReq)
+	if err != nil {
+		return zero, err
+	}
+	defer resp.Body.Close()
+
+	body, err := c.readBody(resp.Body)
+	if err != nil {
+		return zero, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return zero, decodeError(resp.StatusCode, body)
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return zero, nil
+	}
+
+	if c.opts.noEnvelope {
+		var res Res
+		if err := sonic.Unmarshal(body, &res); err != nil {
+			return zero, fmt.Errorf("client: decode response: %w", err)
+		}
+		return res, nil
+	}
+
+	var envelope httpx.Response[Res]
+	if err := sonic.Unmarshal(body, &envelope); err != nil {
+		return zero, fmt.Errorf("client: decode response: %w", err)
+	}
+	return envelope.Data, nil
+}
+
+// readBody 在 maxResponseSize 内读取响应体
+func (c *Client[Req, Res]) readBody(r io.Reader) ([]byte, error) {
+	limit := c.opts.maxResponseSize
+	if limit <= 0 {
+		body, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("client: read response: %w", err)
+		}
+		return body, nil
+	}
+
+	// 多读一个字节，用于区分“恰好等于上限”与“超出上限”
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("client: read response: %w", err)
+	}
+	if int64(len(body)) > limit {
+		return nil, &ResponseTooLargeError{Limit: limit}
+	}
+	return body, nil
+}
+
+// NewRequest 根据 req 的结构体标签构造 *http.Request，但不发送。
+//   - path:   填充 pattern 中的 {wildcard}
+//   - header: 写入请求头
+//   - json:   GET/HEAD/DELETE/OPTIONS 以外的方法，字段按 encoding/json 的规则编码为 JSON Body
+//     (未标注 json 的导出字段同样写入；path/header 字段与只带 form 标签的字段不会进入 Body)
+//   - form:   无 Body 的方法或 JSON Body 已被占用时写入 Query，否则编码为表单 Body；
+//     同时带有 json 标签的字段在有 JSON Body 时只写入 Body
+func (c *Client[Req, Res]) NewRequest(ctx context.Context, req *Req) (*http.Request, error) {
+	if req == nil {
+		req = new(Req)
+	}
+	enc, err := encode(req, hasBody(c.method))
+	if err != nil {
+		return nil, err
+	}
+
+	path, _, err := urlenc.ExpandPath(c.pattern, enc.path)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+
+	var body io.Reader
+	contentType := ""
+	query := enc.form
+
+	if hasBody(c.method) {
+		switch {
+		case enc.json != nil:
+			data, err := sonic.Marshal(enc.json)
+			if err != nil {
+				return nil, fmt.Errorf("client: encode json: %w", err)
+			}
+			body = bytes.NewReader(data)
+			contentType = "application/json"
+		case len(enc.form) > 0:
+			body = strings.NewReader(enc.form.Encode())
+			contentType = "application/x-www-form-urlencoded"
+			query = nil
+		}
+	}
+
+	target := c.opts.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, c.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range c.opts.header {
+		httpReq.Header[k] = append(httpReq.Header[k], vs...)
+	}
+	for k, vs := range enc.header {
+		httpReq.Header[k] = vs
+	}
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	return httpReq, nil
+}
+
+// errorBody 兼容 httpx 信封和 OAuth2/OIDC 风格的自定义错误体
+type errorBody struct {
+	Code             string `json:"code"`
+	Message          string `json:"message"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func decodeError(status int, body []byte) *httpx.HttpError {
+	httpErr := &httpx.HttpError{HttpCode: status}
+
+	var eb errorBody
+	if err := sonic.Unmarshal(body, &eb); err == nil {
+		httpErr.BizCode = eb.Code
+		httpErr.Msg = eb.Message
+		if httpErr.BizCode == "" {
+			httpErr.BizCode = eb.Error
+		}
+		if httpErr.Msg == "" {
+			httpErr.Msg = eb.ErrorDescription
+		}
+	}
+	if httpErr.Msg == "" {
+		httpErr.Msg = http.StatusText(status)
+	}
+	return httpErr
+}
+
+func hasBody(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
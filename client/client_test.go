@@ -0,0 +1,267 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oy3o/httpx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type getUserReq struct {
+	TenantID string   `path:"tenant"`
+	ID       int      `path:"id"`
+	Fields   []string `form:"fields"`
+	Locale   string   `header:"Accept-Language"`
+}
+
+type user struct {
+	ID     int      `json:"id"`
+	Tenant string   `json:"tenant"`
+	Fields []string `json:"fields"`
+	Locale string   `json:"locale"`
+}
+
+type createUserReq struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email"`
+}
+
+type loginReq struct {
+	Username string `form:"username"`
+	Password string `form:"password"`
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	router := httpx.NewRouter()
+	router.Handle("GET /tenants/{tenant}/users/{id}", httpx.NewHandler(func(ctx context.Context, req *struct {
+		TenantID string   `path:"tenant"`
+		ID       int      `path:"id"`
+		Fields   []string `form:"fields"`
+	}) (*user, error) {
+		if req.ID == 404 {
+			return nil, httpx.NewError(http.StatusNotFound, "USER_NOT_FOUND", "user not found")
+		}
+		return &user{ID: req.ID, Tenant: req.TenantID, Fields: req.Fields}, nil
+	}))
+	router.Handle("POST /users", httpx.NewHandler(func(ctx context.Context, req *createUserReq) (*user, error) {
+		return &user{ID: 1, Tenant: req.Name}, nil
+	}))
+	router.Handle("POST /login", httpx.NewHandler(func(ctx context.Context, req *loginReq) (string, error) {
+		if req.Password != "secret" {
+			return "", httpx.ErrUnauthorized
+		}
+		return "token-" + req.Username, nil
+	}, httpx.NoEnvelope()))
+	router.HandleFunc("GET /oidc", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant","error_description":"code expired"}`))
+	})
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_PathQueryHeader(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient[getUserReq, *user](http.MethodGet, "/tenants/{tenant}/users/{id}", WithBaseURL(srv.URL))
+
+	res, err := c.Call(context.Background(), &getUserReq{TenantID: "acme corp", ID: 7, Fields: []string{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, 7, res.ID)
+	assert.Equal(t, "acme corp", res.Tenant)
+	assert.Equal(t, []string{"a", "b"}, res.Fields)
+}
+
+func TestClient_NewRequest(t *testing.T) {
+	c := NewClient[getUserReq, *user](http.MethodGet, "/tenants/{tenant}/users/{id}",
+		WithBaseURL("http://svc/"), WithHeader("X-Caller", "billing"))
+
+	req, err := c.NewRequest(context.Background(), &getUserReq{TenantID: "a/b", ID: 1, Locale: "zh-CN"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://svc/tenants/a%2Fb/users/1", req.URL.String())
+	assert.Equal(t, "zh-CN", req.Header.Get("Accept-Language"))
+	assert.Equal(t, "billing", req.Header.Get("X-Caller"))
+	assert.Nil(t, req.Body)
+}
+
+func TestClient_JSONBody(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient[createUserReq, *user](http.MethodPost, "/users", WithBaseURL(srv.URL))
+
+	res, err := c.Call(context.Background(), &createUserReq{Name: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", res.Tenant)
+
+	// 校验失败被还原为 HttpError
+	_, err = c.Call(context.Background(), &createUserReq{})
+	var httpErr *httpx.HttpError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusBadRequest, httpErr.HttpCode)
+	assert.Equal(t, httpx.CodeValidation, httpErr.BizCode)
+}
+
+func TestClient_FormBodyNoEnvelope(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient[loginReq, string](http.MethodPost, "/login", WithBaseURL(srv.URL), NoEnvelope())
+
+	token, err := c.Call(context.Background(), &loginReq{Username: "bob", Password: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "token-bob", token)
+
+	_, err = c.Call(context.Background(), &loginReq{Username: "bob", Password: "wrong"})
+	var httpErr *httpx.HttpError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusUnauthorized, httpErr.HttpCode)
+	assert.Equal(t, httpx.CodeUnauthorized, httpErr.BizCode)
+}
+
+func TestClient_Errors(t *testing.T) {
+	srv := newTestServer(t)
+
+	t.Run("BizCode", func(t *testing.T) {
+		c := NewClient[getUserReq, *user](http.MethodGet, "/tenants/{tenant}/users/{id}", WithBaseURL(srv.URL))
+		_, err := c.Call(context.Background(), &getUserReq{TenantID: "acme", ID: 404})
+
+		var httpErr *httpx.HttpError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusNotFound, httpErr.HttpCode)
+		assert.Equal(t, "USER_NOT_FOUND", httpErr.BizCode)
+		assert.Equal(t, "user not found", httpErr.Msg)
+	})
+
+	t.Run("OIDCStyle", func(t *testing.T) {
+		c := NewClient[struct{}, any](http.MethodGet, "/oidc", WithBaseURL(srv.URL))
+		_, err := c.Call(context.Background(), nil)
+
+		var httpErr *httpx.HttpError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, "invalid_grant", httpErr.BizCode)
+		assert.Equal(t, "code expired", httpErr.Msg)
+	})
+
+	t.Run("MissingPathValue", func(t *testing.T) {
+		c := NewClient[struct{}, any](http.MethodGet, "/users/{id}", WithBaseURL(srv.URL))
+		_, err := c.Call(context.Background(), nil)
+		assert.ErrorContains(t, err, `missing path value "id"`)
+	})
+}
+
+func TestClient_BodyExcludesPathAndHeader(t *testing.T) {
+	type updateReq struct {
+		ID    int    `path:"id"`
+		Token string `header:"Authorization"`
+		Name  string `json:"name"`
+		Note  string `json:"note,omitempty"`
+	}
+	c := NewClient[updateReq, any](http.MethodPut, "/u/{id}", WithBaseURL("http://svc"))
+
+	req, err := c.NewRequest(context.Background(), &updateReq{ID: 1, Token: "Bearer s3cret", Name: "n"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://svc/u/1", req.URL.String())
+	assert.Equal(t, "Bearer s3cret", req.Header.Get("Authorization"))
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"n"}`, string(body), "path and header fields must not leak into the body")
+}
+
+func TestClient_JSONFormFieldWithoutBody(t *testing.T) {
+	type listReq struct {
+		ID    int    `path:"id"`
+		Page  int    `json:"page" form:"page"`
+		Query string `json:"q" form:"q"`
+	}
+	for _, method := range []string{http.MethodGet, http.MethodDelete, http.MethodHead} {
+		c := NewClient[listReq, any](method, "/u/{id}", WithBaseURL("http://svc"))
+		req, err := c.NewRequest(context.Background(), &listReq{ID: 1, Page: 2, Query: "a b"})
+		require.NoError(t, err)
+		assert.Equal(t, "http://svc/u/1?page=2&q=a+b", req.URL.String(), method)
+		assert.Nil(t, req.Body, method)
+	}
+
+	// 有 Body 的方法中，同时带 json 标签的字段只写入 JSON Body
+	c := NewClient[listReq, any](http.MethodPost, "/u/{id}", WithBaseURL("http://svc"))
+	req, err := c.NewRequest(context.Background(), &listReq{ID: 1, Page: 2, Query: "x"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://svc/u/1", req.URL.String())
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"page":2,"q":"x"}`, string(body))
+}
+
+func TestClient_JSONFieldNames(t *testing.T) {
+	type audit struct {
+		By string `json:"by"`
+	}
+	type Meta struct {
+		Region string `json:"region"`
+	}
+	type patchReq struct {
+		ID   int `path:"id"`
+		Meta `json:"meta"`
+		audit
+		Name  string `json:",omitempty"`
+		Email string
+		Page  int    `form:"page"`
+		Skip  string `json:"-"`
+	}
+	c := NewClient[patchReq, any](http.MethodPatch, "/u/{id}", WithBaseURL("http://svc"))
+
+	req, err := c.NewRequest(context.Background(), &patchReq{
+		ID: 1, Meta: Meta{Region: "eu"}, audit: audit{By: "ops"},
+		Name: "n", Email: "a@example.com", Page: 2, Skip: "x",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "http://svc/u/1?page=2", req.URL.String())
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	// 与 encoding/json 一致：空名称使用字段名，带名称的嵌入结构体是具名字段，未标注的导出字段同样写入
+	assert.JSONEq(t, `{"meta":{"region":"eu"},"by":"ops","Name":"n","Email":"a@example.com"}`, string(body))
+}
+
+func TestClient_BytesQueryRoundTrip(t *testing.T) {
+	type echoReq struct {
+		Cursor []byte `form:"cursor"`
+	}
+	router := httpx.NewRouter()
+	router.Handle("GET /echo", httpx.NewHandler(func(ctx context.Context, req *echoReq) (string, error) {
+		return string(req.Cursor), nil
+	}))
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	c := NewClient[echoReq, string](http.MethodGet, "/echo", WithBaseURL(srv.URL))
+	req, err := c.NewRequest(context.Background(), &echoReq{Cursor: []byte("page 2")})
+	require.NoError(t, err)
+	assert.Equal(t, "cursor=page+2", req.URL.RawQuery)
+
+	res, err := c.Call(context.Background(), &echoReq{Cursor: []byte("page 2")})
+	require.NoError(t, err)
+	assert.Equal(t, "page 2", res)
+}
+
+func TestClient_MaxResponseSize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`"0123456789"`))
+	}))
+	t.Cleanup(srv.Close)
+
+	// 恰好等于上限时正常解码
+	c := NewClient[struct{}, string](http.MethodGet, "/", WithBaseURL(srv.URL), NoEnvelope(), WithMaxResponseSize(12))
+	res, err := c.Call(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", res)
+
+	c = NewClient[struct{}, string](http.MethodGet, "/", WithBaseURL(srv.URL), NoEnvelope(), WithMaxResponseSize(8))
+	_, err = c.Call(context.Background(), nil)
+	var tooLarge *ResponseTooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+	assert.Equal(t, int64(8), tooLarge.Limit)
+}
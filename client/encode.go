@@ -0,0 +1,189 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/oy3o/httpx/internal/urlenc"
+)
+
+// fieldKind 标识字段在请求中的去向
+type fieldKind int
+
+const (
+	fieldPath fieldKind = iota
+	fieldHeader
+	fieldForm
+)
+
+type fieldInfo struct {
+	index []int
+	kind  fieldKind
+	key   string
+	json  bool // 同时带有 json 标签：有 JSON Body 时写入 Body 而不是 Query
+}
+
+type typeInfo struct {
+	fields []fieldInfo
+	// jsonFields 与 jsonType 描述 JSON Body：字段的选取与命名遵循 encoding/json，
+	// 但排除 path/header 字段，以及只带 form 标签的字段 (它们写入 Query 或表单)。
+	// jsonType 是仅由这些字段构成的结构体类型 (保留 json 选项)，jsonFields[i] 对应它的第 i 个字段。
+	jsonFields [][]int
+	jsonType   reflect.Type
+}
+
+// typeCache 缓存 reflect.Type -> *typeInfo，与服务端 metaCache 的思路一致
+var typeCache sync.Map
+
+func getTypeInfo(t reflect.Type) *typeInfo {
+	if v, ok := typeCache.Load(t); ok {
+		return v.(*typeInfo)
+	}
+
+	info := &typeInfo{}
+	var jsonStructFields []reflect.StructField
+	var walk func(typ reflect.Type, base []int)
+	walk = func(typ reflect.Type, base []int) {
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			idx := append(append([]int(nil), base...), i)
+
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			jsonTag, hasJSON := field.Tag.Lookup("json")
+			// 与 encoding/json 一致：带名称的 json 标签使嵌入结构体成为普通的具名字段
+			if field.Anonymous && ft.Kind() == reflect.Struct && (!field.IsExported() || tagName(jsonTag) == "") {
+				walk(ft, idx)
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+
+			if key := field.Tag.Get("path"); key != "" {
+				info.fields = append(info.fields, fieldInfo{index: idx, kind: fieldPath, key: key})
+				continue
+			}
+			if key := field.Tag.Get("header"); key != "" {
+				info.fields = append(info.fields, fieldInfo{index: idx, kind: fieldHeader, key: http.CanonicalHeaderKey(key)})
+				continue
+			}
+			formKey := tagName(field.Tag.Get("form"))
+			// 没有 json 标签的字段按 encoding/json 的规则以字段名写入 Body，除非它已声明为 form 字段
+			isJSON := jsonTag != "-" && (hasJSON || formKey == "" || formKey == "-")
+			if isJSON {
+				if tagName(jsonTag) == "" {
+					jsonTag = field.Name + jsonTag
+				}
+				info.jsonFields = append(info.jsonFields, idx)
+				jsonStructFields = append(jsonStructFields, reflect.StructField{
+					Name: "F" + strconv.Itoa(len(jsonStructFields)),
+					Type: field.Type,
+					Tag:  reflect.StructTag(`json:"` + jsonTag + `"`),
+				})
+			}
+			if formKey != "" && formKey != "-" {
+				info.fields = append(info.fields, fieldInfo{index: idx, kind: fieldForm, key: formKey, json: isJSON})
+			}
+		}
+	}
+	if t.Kind() == reflect.Struct {
+		walk(t, nil)
+	}
+	if len(jsonStructFields) > 0 {
+		info.jsonType = reflect.StructOf(jsonStructFields)
+	}
+
+	actual, _ := typeCache.LoadOrStore(t, info)
+	return actual.(*typeInfo)
+}
+
+func tagName(tag string) string {
+	if idx := strings.IndexByte(tag, ','); idx != -1 {
+		return tag[:idx]
+	}
+	return tag
+}
+
+type encoded struct {
+	path   map[string]string
+	header http.Header
+	form   url.Values
+	// json 是待编码为 JSON Body 的值，仅在方法允许 Body 且存在 json 字段时非 nil
+	json any
+}
+
+// encode 按标签拆分 v 的字段。body 表示请求方法允许携带 Body：
+// 此时 json 字段组成 JSON Body；否则同时带有 form 标签的 json 字段与其他 form 字段一起写入 Query。
+func encode(v any, body bool) (*encoded, error) {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return &encoded{}, nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return &encoded{}, nil
+	}
+
+	info := getTypeInfo(val.Type())
+	enc := &encoded{}
+	jsonBody := body && info.jsonType != nil
+
+	for _, f := range info.fields {
+		if f.json && jsonBody {
+			continue
+		}
+		fv, ok := urlenc.FieldByIndex(val, f.index)
+		if !ok {
+			continue
+		}
+		values, err := urlenc.FormatValues(fv)
+		if err != nil {
+			return nil, fmt.Errorf("client: field %q: %w", f.key, err)
+		}
+
+		switch f.kind {
+		case fieldPath:
+			if enc.path == nil {
+				enc.path = make(map[string]string)
+			}
+			enc.path[f.key] = strings.Join(values, "/")
+		case fieldHeader:
+			if len(values) == 0 {
+				continue
+			}
+			if enc.header == nil {
+				enc.header = make(http.Header)
+			}
+			enc.header[f.key] = values
+		case fieldForm:
+			if len(values) == 0 || (len(values) == 1 && fv.IsZero()) {
+				continue
+			}
+			if enc.form == nil {
+				enc.form = make(url.Values)
+			}
+			enc.form[f.key] = values
+		}
+	}
+
+	if jsonBody {
+		out := reflect.New(info.jsonType).Elem()
+		for i, idx := range info.jsonFields {
+			if fv, ok := urlenc.FieldByIndex(val, idx); ok {
+				out.Field(i).Set(fv)
+			}
+		}
+		enc.json = out.Interface()
+	}
+	return enc, nil
+}
@@ -68,6 +68,11 @@ func GetCookie(r *http.Request, name string) (string, error) {
 // SetCookie 写入 Cookie。
 // 除非显式使用 WithInsecure() 或设置了 Domain，否则它会自动添加 __Host- 前缀。
 func SetCookie(w http.ResponseWriter, name string, value string, opts ...CookieOption) {
+	http.SetCookie(w, newCookie(name, value, opts))
+}
+
+// newCookie 按默认的安全配置与 opts 构造 Cookie，并加上合适的前缀
+func newCookie(name string, value string, opts []CookieOption) *http.Cookie {
 	// 默认配置：最安全的基准
 	c := &http.Cookie{
 		Name:     name,
@@ -84,8 +89,7 @@ func SetCookie(w http.ResponseWriter, name string, value string, opts ...CookieO
 
 	// 关键时刻：根据最终配置决定披什么甲
 	c.Name = resolveWriteName(name, c)
-
-	http.SetCookie(w, c)
+	return c
 }
 
 // CookieOption用于配置 Cookie 的属性。
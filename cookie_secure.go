@@ -0,0 +1,164 @@
+package httpx
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// 签名/加密 Cookie 的错误
+var (
+	ErrCookieInvalid  = errors.New("httpx: cookie is invalid")
+	ErrCookieExpired  = errors.New("httpx: cookie is expired")
+	ErrCookieTooLarge = errors.New("httpx: cookie exceeds 4096 bytes")
+)
+
+// maxCookieSize 浏览器普遍支持的单个 Cookie 上限 (名称 + 值)
+const maxCookieSize = 4096
+
+// CookieKeys 是签名/加密 Cookie 的密钥环。
+// 第一个密钥用于签名与加密，所有密钥都可用于验证与解密：
+// 轮换时把新密钥放在最前面，旧密钥保留到它签发的 Cookie 全部过期后再移除。
+type CookieKeys struct {
+	macKeys [][]byte
+	aeads   []cipher.AEAD
+}
+
+// NewCookieKeys 创建密钥环，每个 secret 至少 32 字节。
+// 签名与加密使用经 HKDF 派生的不同子密钥，因此同一个密钥环可以同时用于两者。
+func NewCookieKeys(secrets ...[]byte) *CookieKeys {
+	if len(secrets) == 0 {
+		panic("httpx: NewCookieKeys requires at least one secret")
+	}
+	k := &CookieKeys{}
+	for _, secret := range secrets {
+		if len(secret) < 32 {
+			panic("httpx: cookie secret must be at least 32 bytes")
+		}
+		macKey, err := hkdf.Key(sha256.New, secret, nil, "httpx signed cookie", 32)
+		if err != nil {
+			panic(err)
+		}
+		encKey, err := hkdf.Key(sha256.New, secret, nil, "httpx encrypted cookie", 32)
+		if err != nil {
+			panic(err)
+		}
+		block, _ := aes.NewCipher(encKey)
+		aead, _ := cipher.NewGCM(block)
+		k.macKeys = append(k.macKeys, macKey)
+		k.aeads = append(k.aeads, aead)
+	}
+	return k
+}
+
+// SetSignedCookie 写入一个 HMAC-SHA256 签名的 Cookie。值本身以明文 (base64) 保存，客户端可见但不可篡改。
+// 有效期 (WithCookieTTL) 会被写入签名内容，过期的 Cookie 即使被客户端保留也会被拒绝；
+// Cookie 名称参与签名，不同 Cookie 之间的值无法互换。前缀规则与 SetCookie 相同。
+func SetSignedCookie(w http.ResponseWriter, name string, value string, keys *CookieKeys, opts ...CookieOption) error {
+	c := newCookie(name, "", opts)
+	payload := cookiePayload(c, value)
+	mac := cookieMAC(keys.macKeys[0], name, payload)
+	c.Value = base64.RawURLEncoding.EncodeToString(append(payload, mac...))
+	return writeSecureCookie(w, c)
+}
+
+// GetSignedCookie 读取并验证 SetSignedCookie 写入的 Cookie。
+// Cookie 不存在时返回 http.ErrNoCookie，签名不匹配时返回 ErrCookieInvalid，过期时返回 ErrCookieExpired。
+func GetSignedCookie(r *http.Request, name string, keys *CookieKeys) (string, error) {
+	raw, err := GetCookie(r, name)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil || len(data) < 8+sha256.Size {
+		return "", ErrCookieInvalid
+	}
+	payload, mac := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	for _, key := range keys.macKeys {
+		if hmac.Equal(mac, cookieMAC(key, name, payload)) {
+			return openCookiePayload(payload)
+		}
+	}
+	return "", ErrCookieInvalid
+}
+
+// SetEncryptedCookie 写入一个 AES-256-GCM 加密的 Cookie，客户端既无法读取也无法篡改其内容。
+// 有效期与名称绑定规则同 SetSignedCookie (名称作为 AEAD 的附加数据)。
+func SetEncryptedCookie(w http.ResponseWriter, name string, value string, keys *CookieKeys, opts ...CookieOption) error {
+	c := newCookie(name, "", opts)
+	aead := keys.aeads[0]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+8+len(value)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := aead.Seal(nonce, nonce, cookiePayload(c, value), []byte(name))
+	c.Value = base64.RawURLEncoding.EncodeToString(sealed)
+	return writeSecureCookie(w, c)
+}
+
+// GetEncryptedCookie 读取并解密 SetEncryptedCookie 写入的 Cookie，错误语义同 GetSignedCookie。
+func GetEncryptedCookie(r *http.Request, name string, keys *CookieKeys) (string, error) {
+	raw, err := GetCookie(r, name)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	// 所有密钥都是 AES-256-GCM，nonce 与 tag 长度相同
+	if err != nil || len(data) < keys.aeads[0].NonceSize()+keys.aeads[0].Overhead() {
+		return "", ErrCookieInvalid
+	}
+	for _, aead := range keys.aeads {
+		nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+		if payload, err := aead.Open(nil, nonce, sealed, []byte(name)); err == nil {
+			return openCookiePayload(payload)
+		}
+	}
+	return "", ErrCookieInvalid
+}
+
+// cookiePayload 编码为 expiry (8 字节 Unix 秒，0 表示会话 Cookie) || value
+func cookiePayload(c *http.Cookie, value string) []byte {
+	var expires int64
+	if c.MaxAge > 0 {
+		expires = time.Now().Add(time.Duration(c.MaxAge) * time.Second).Unix()
+	}
+	payload := make([]byte, 8, 8+len(value))
+	binary.BigEndian.PutUint64(payload, uint64(expires))
+	return append(payload, value...)
+}
+
+func openCookiePayload(payload []byte) (string, error) {
+	if len(payload) < 8 {
+		return "", ErrCookieInvalid
+	}
+	if expires := int64(binary.BigEndian.Uint64(payload)); expires != 0 && time.Now().Unix() >= expires {
+		return "", ErrCookieExpired
+	}
+	return string(payload[8:]), nil
+}
+
+// cookieMAC 计算 HMAC-SHA256(name || 0x00 || payload)。
+// 使用逻辑名称 (不含 __Host-/__Secure- 前缀)，与 GetCookie 的前缀探测保持一致
+func cookieMAC(key []byte, name string, payload []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+func writeSecureCookie(w http.ResponseWriter, c *http.Cookie) error {
+	if len(c.Name)+len(c.Value) > maxCookieSize {
+		return ErrCookieTooLarge
+	}
+	http.SetCookie(w, c)
+	return nil
+}
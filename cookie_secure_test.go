@@ -0,0 +1,134 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type secureCookieCodec struct {
+	name string
+	set  func(w http.ResponseWriter, name, value string, keys *CookieKeys, opts ...CookieOption) error
+	get  func(r *http.Request, name string, keys *CookieKeys) (string, error)
+}
+
+var secureCookieCodecs = []secureCookieCodec{
+	{name: "Signed", set: SetSignedCookie, get: GetSignedCookie},
+	{name: "Encrypted", set: SetEncryptedCookie, get: GetEncryptedCookie},
+}
+
+// roundTripCookie 将 w 中写入的 Cookie 原样带到一个新请求上
+func roundTripCookie(w *httptest.ResponseRecorder) *http.Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	for _, c := range w.Result().Cookies() {
+		r.AddCookie(c)
+	}
+	return r
+}
+
+func TestSecureCookies(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+	keys := NewCookieKeys(oldKey)
+	rotated := NewCookieKeys(newKey, oldKey)
+
+	for _, codec := range secureCookieCodecs {
+		t.Run(codec.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			require.NoError(t, codec.set(w, "session", "user-1|admin", keys, WithCookieTTL(time.Hour)))
+			cookie := w.Result().Cookies()[0]
+			assert.Equal(t, "__Host-session", cookie.Name, "prefix logic is kept")
+			if codec.name == "Encrypted" {
+				assert.NotContains(t, cookie.Value, base64.RawURLEncoding.EncodeToString([]byte("user-1")))
+			}
+
+			r := roundTripCookie(w)
+			got, err := codec.get(r, "session", keys)
+			require.NoError(t, err)
+			assert.Equal(t, "user-1|admin", got)
+
+			// 轮换：新密钥环仍能读取旧 Cookie，新 Cookie 使用新密钥
+			got, err = codec.get(r, "session", rotated)
+			require.NoError(t, err)
+			assert.Equal(t, "user-1|admin", got)
+			w = httptest.NewRecorder()
+			require.NoError(t, codec.set(w, "session", "v2", rotated))
+			_, err = codec.get(roundTripCookie(w), "session", keys)
+			assert.ErrorIs(t, err, ErrCookieInvalid, "old keyring cannot read cookies from the new key")
+
+			// 名称绑定：把 session 的值放到 remember 中
+			swapped := httptest.NewRequest("GET", "/", nil)
+			swapped.AddCookie(&http.Cookie{Name: "__Host-remember", Value: cookie.Value})
+			_, err = codec.get(swapped, "remember", keys)
+			assert.ErrorIs(t, err, ErrCookieInvalid)
+
+			// 篡改
+			tampered := httptest.NewRequest("GET", "/", nil)
+			v := []byte(cookie.Value)
+			v[len(v)/2] ^= 'A' ^ 'B'
+			tampered.AddCookie(&http.Cookie{Name: cookie.Name, Value: string(v)})
+			_, err = codec.get(tampered, "session", keys)
+			assert.ErrorIs(t, err, ErrCookieInvalid)
+
+			for _, bad := range []string{"", "!!", "AAAA"} {
+				r := httptest.NewRequest("GET", "/", nil)
+				r.AddCookie(&http.Cookie{Name: "session", Value: bad})
+				_, err = codec.get(r, "session", keys)
+				assert.ErrorIs(t, err, ErrCookieInvalid, bad)
+			}
+
+			_, err = codec.get(httptest.NewRequest("GET", "/", nil), "session", keys)
+			assert.ErrorIs(t, err, http.ErrNoCookie)
+		})
+	}
+}
+
+func TestSecureCookies_Expiry(t *testing.T) {
+	keys := NewCookieKeys(bytes.Repeat([]byte{3}, 32))
+
+	// 模拟客户端无视 Max-Age 保留了一个早已过期的 Cookie
+	expired := make([]byte, 8, 10)
+	binary.BigEndian.PutUint64(expired, uint64(time.Now().Add(-time.Minute).Unix()))
+	expired = append(expired, "hi"...)
+	nonce := make([]byte, keys.aeads[0].NonceSize())
+	forged := map[string]string{
+		"Signed":    base64.RawURLEncoding.EncodeToString(append(bytes.Clone(expired), cookieMAC(keys.macKeys[0], "flash", expired)...)),
+		"Encrypted": base64.RawURLEncoding.EncodeToString(keys.aeads[0].Seal(nonce, nonce, expired, []byte("flash"))),
+	}
+
+	for _, codec := range secureCookieCodecs {
+		t.Run(codec.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			require.NoError(t, codec.set(w, "flash", "hi", keys, WithCookieTTL(time.Minute), WithInsecure()))
+			cookie := w.Result().Cookies()[0]
+			assert.Equal(t, "flash", cookie.Name)
+			got, err := codec.get(roundTripCookie(w), "flash", keys)
+			require.NoError(t, err)
+			assert.Equal(t, "hi", got)
+
+			r := httptest.NewRequest("GET", "/", nil)
+			r.AddCookie(&http.Cookie{Name: "flash", Value: forged[codec.name]})
+			_, err = codec.get(r, "flash", keys)
+			assert.ErrorIs(t, err, ErrCookieExpired)
+		})
+	}
+
+	payload := cookiePayload(&http.Cookie{MaxAge: 60}, "x")
+	assert.InDelta(t, time.Now().Add(time.Minute).Unix(), int64(binary.BigEndian.Uint64(payload)), 2)
+	assert.Zero(t, binary.BigEndian.Uint64(cookiePayload(&http.Cookie{}, "x")), "session cookies have no embedded expiry")
+
+	w := httptest.NewRecorder()
+	assert.ErrorIs(t, SetSignedCookie(w, "big", strings.Repeat("x", 4096), keys), ErrCookieTooLarge)
+	assert.Empty(t, w.Result().Cookies())
+
+	assert.Panics(t, func() { NewCookieKeys([]byte("short")) })
+	assert.Panics(t, func() { NewCookieKeys() })
+}
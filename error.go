@@ -3,6 +3,7 @@ package httpx
 import (
 	"context"
 	"net/http"
+	"strings"
 )
 
 // SafeMode 控制是否开启错误脱敏。
@@ -33,6 +34,12 @@ const (
 	// CodeNotFound 资源不存在 (404)
 	CodeNotFound = "NOT_FOUND"
 
+	// CodeMethodNotAllowed 请求方法不被允许 (405)
+	CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+
+	// CodeGone 资源已永久下线 (410)
+	CodeGone = "GONE"
+
 	// CodeTooManyRequests 请求过多 (429)
 	CodeTooManyRequests = "TOO_MANY_REQUESTS"
 
@@ -54,6 +61,8 @@ var (
 	ErrUnauthorized          = &HttpError{HttpCode: http.StatusUnauthorized, BizCode: CodeUnauthorized, Msg: "Unauthorized"}
 	ErrForbidden             = &HttpError{HttpCode: http.StatusForbidden, BizCode: CodeForbidden, Msg: "Forbidden"}
 	ErrNotFound              = &HttpError{HttpCode: http.StatusNotFound, BizCode: CodeNotFound, Msg: "Not Found"}
+	ErrMethodNotAllowed      = &HttpError{HttpCode: http.StatusMethodNotAllowed, BizCode: CodeMethodNotAllowed, Msg: "Method Not Allowed"}
+	ErrGone                  = &HttpError{HttpCode: http.StatusGone, BizCode: CodeGone, Msg: "Gone"}
 	ErrTooManyRequests       = &HttpError{HttpCode: http.StatusTooManyRequests, BizCode: CodeTooManyRequests, Msg: "Too Many Requests"}
 	ErrInternal              = &HttpError{HttpCode: http.StatusInternalServerError, BizCode: CodeInternalError, Msg: "Internal Server Error"}
 	ErrRequestEntityTooLarge = &HttpError{HttpCode: http.StatusRequestEntityTooLarge, BizCode: CodeRequestEntityTooLarge, Msg: "Request Entity Too Large"}
@@ -78,12 +87,20 @@ type PublicError interface {
 	PublicMessage() string
 }
 
+// HeaderError 定义了错误响应需要附带的响应头 (如 405 的 Allow、401 的 WWW-Authenticate)。
+// Error 会在写入状态码之前将其合并到 ResponseWriter 中。
+type HeaderError interface {
+	ResponseHeader() http.Header
+}
+
 // HttpError 是一个通用的错误实现，同时满足 error, ErrorCoder 和 BizCoder 接口。
 // HttpError 被视为“安全的”，因为它是开发者显式构造的业务错误。
 type HttpError struct {
 	HttpCode int
 	BizCode  string
 	Msg      string
+	// Header 随错误响应一起写出的响应头 (如 WWW-Authenticate)，可为 nil。
+	Header http.Header `json:"-"`
 }
 
 func (e *HttpError) Error() string { return e.Msg }
@@ -94,6 +111,28 @@ func (e *HttpError) BizStatus() string { return e.BizCode }
 
 func (e *HttpError) PublicMessage() string { return e.Msg }
 
+func (e *HttpError) ResponseHeader() http.Header { return e.Header }
+
+// MethodNotAllowedError 表示请求方法不被允许 (405)，并通过 Allow 头告知客户端支持的方法。
+// errors.Is(err, ErrMethodNotAllowed) 对其成立。
+type MethodNotAllowedError struct {
+	Allow []string
+}
+
+func (e *MethodNotAllowedError) Error() string { return ErrMethodNotAllowed.Msg }
+
+func (e *MethodNotAllowedError) HTTPStatus() int { return http.StatusMethodNotAllowed }
+
+func (e *MethodNotAllowedError) BizStatus() string { return CodeMethodNotAllowed }
+
+func (e *MethodNotAllowedError) PublicMessage() string { return ErrMethodNotAllowed.Msg }
+
+func (e *MethodNotAllowedError) Is(target error) bool { return target == ErrMethodNotAllowed }
+
+func (e *MethodNotAllowedError) ResponseHeader() http.Header {
+	return http.Header{"Allow": {strings.Join(e.Allow, ", ")}}
+}
+
 // NewError 创建一个新的 HttpError。
 // httpCode: HTTP 状态码 (如 404)
 // bizCode: 业务错误码 (如 "USER_NOT_FOUND")
@@ -29,6 +29,7 @@ type errorConfig struct {
 	hook       func(context.Context, error)
 	noEnvelope bool
 	status     int // 允许强制覆盖状态码
+	panicHook  func(*http.Request, *PanicError)
 }
 
 // WithHandler 注入实际错误处理函数
@@ -59,6 +60,14 @@ func WithStatus(code int) ErrorOption {
 	}
 }
 
+// WithPanicHook 注入 panic 观测钩子，仅对 Recovery 生效。
+// 钩子在写出错误响应之前调用，适合用于统计 panic 次数 (参见 Metrics.PanicHook)。
+func WithPanicHook(fn func(r *http.Request, p *PanicError)) ErrorOption {
+	return func(cfg *errorConfig) {
+		cfg.panicHook = fn
+	}
+}
+
 // Error 负责将 error 转换为 HTTP 响应并写入 ResponseWriter。
 func Error(w http.ResponseWriter, r *http.Request, err error, opts ...ErrorOption) {
 	// 1. 初始化默认配置
@@ -105,6 +114,7 @@ func Error(w http.ResponseWriter, r *http.Request, err error, opts ...ErrorOptio
 	if cfg.status != 0 {
 		httpCode = cfg.status
 	}
+	recordBizCode(r.Context(), bizCode)
 
 	// 6. 安全模式下的错误脱敏 (Red Team Security Logic)
 	if SafeMode {
@@ -129,6 +139,11 @@ func Error(w http.ResponseWriter, r *http.Request, err error, opts ...ErrorOptio
 
 	// 7. 写入响应头
 	// w.WriteHeader(httpCode) moved to step 9
+	if e, ok := err.(HeaderError); ok {
+		for k, v := range e.ResponseHeader() {
+			w.Header()[http.CanonicalHeaderKey(k)] = v
+		}
+	}
 
 	// 自动注入 TraceID
 	var traceID string
@@ -204,6 +219,10 @@ func inferBizCode(httpCode int) string {
 		return CodeForbidden
 	case http.StatusNotFound:
 		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusGone:
+		return CodeGone
 	case http.StatusTooManyRequests:
 		return CodeTooManyRequests
 	case http.StatusConflict:
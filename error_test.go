@@ -103,3 +103,14 @@ type customPublicError struct {
 
 func (e *customPublicError) Error() string         { return "sensitive info" }
 func (e *customPublicError) PublicMessage() string { return e.msg }
+
+func TestError_MethodNotAllowed(t *testing.T) {
+	err := &MethodNotAllowedError{Allow: []string{"GET", "POST"}}
+	assert.ErrorIs(t, err, ErrMethodNotAllowed)
+
+	w := httptest.NewRecorder()
+	Error(w, httptest.NewRequest("DELETE", "/", nil), err)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, POST", w.Header().Get("Allow"))
+	assert.Contains(t, w.Body.String(), CodeMethodNotAllowed)
+}
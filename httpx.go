@@ -67,6 +67,8 @@ func NewHandler[Req any, Res any](fn HandlerFunc[Req, Res], opts ...Option) http
 			return
 		}
 
+		recordBizCode(r.Context(), CodeOK)
+
 		// JSON 响应
 		w.Header()["Content-Type"] = jsonContentType
 		w.WriteHeader(http.StatusOK)
@@ -144,6 +146,8 @@ func NewStreamHandler[Req any, Res Streamable](fn HandlerFunc[Req, Res], opts ..
 			return
 		}
 
+		recordBizCode(r.Context(), CodeOK)
+
 		// 处理流式响应
 		for k, v := range res.Headers() {
 			w.Header().Set(k, v)
@@ -159,6 +163,11 @@ func prepare[Req any, Res any](w http.ResponseWriter, r *http.Request, cfg *conf
 	ctx := r.Context()
 	errFunc := cfg.errorFunc
 
+	// 0. 废弃接口: 写入 Deprecation/Sunset 头，下线后直接返回 410
+	if cfg.deprecation != nil && !cfg.deprecation.serve(w, r, errFunc, WithHook(cfg.errorHook)) {
+		return
+	}
+
 	// 1. 应用 Body 大小限制
 	if cfg.maxBodySize > 0 && r.Body != nil && r.Body != http.NoBody {
 		// http.MaxBytesReader 会包装 r.Body。
@@ -177,6 +186,12 @@ func prepare[Req any, Res any](w http.ResponseWriter, r *http.Request, cfg *conf
 			errFunc(w, r, ErrRequestEntityTooLarge, WithHook(cfg.errorHook))
 			return
 		}
+		// Binder 返回的带状态码的错误 (如 *OAuthError) 原样交给 ErrorFunc
+		var coder ErrorCoder
+		if errors.As(err, &coder) {
+			errFunc(w, r, err, WithHook(cfg.errorHook))
+			return
+		}
 		errFunc(w, r, &HttpError{HttpCode: http.StatusBadRequest, Msg: err.Error()}, WithHook(cfg.errorHook))
 		return
 	}
@@ -188,7 +203,15 @@ func prepare[Req any, Res any](w http.ResponseWriter, r *http.Request, cfg *conf
 		return
 	}
 
-	// 4. 业务逻辑 (Business Logic)
+	// 4. 授权 (Authorization): 资源级策略需要绑定后的请求
+	for _, policy := range cfg.policies {
+		if err := policy(ctx, GetIdentity(ctx), &req); err != nil {
+			errFunc(w, r, err, WithHook(cfg.errorHook))
+			return
+		}
+	}
+
+	// 5. 业务逻辑 (Business Logic)
 	// 直接传递标准 Context
 	res, err := fn(ctx, &req)
 	if err != nil {
@@ -196,7 +219,7 @@ func prepare[Req any, Res any](w http.ResponseWriter, r *http.Request, cfg *conf
 		return
 	}
 
-	// 5. 处理成功路径: 自动在 Response Header 中注入 TraceID
+	// 6. 处理成功路径: 自动在 Response Header 中注入 TraceID
 	// 失败路径: errFunc 内部处理
 	// 这样无论业务逻辑是否成功，客户端都能通过 Header 拿到 TraceID
 	if GetTraceID != nil {
@@ -0,0 +1,113 @@
+package httpxtest
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/oy3o/httpx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type updateReq struct {
+	ID     int    `path:"id"`
+	Name   string `json:"name" validate:"required"`
+	Notify bool   `form:"notify"`
+}
+
+type item struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Notify bool   `json:"notify"`
+	Owner  any    `json:"owner"`
+	Cookie string `json:"cookie,omitempty"`
+}
+
+func update(ctx context.Context, req *updateReq) (*item, error) {
+	if req.ID == 0 {
+		return nil, httpx.ErrNotFound
+	}
+	return &item{ID: req.ID, Name: req.Name, Notify: req.Notify, Owner: httpx.GetIdentity(ctx)}, nil
+}
+
+func TestRequestBuilder_DirectHandler(t *testing.T) {
+	rec := NewRequest("PUT", "/items/42").
+		PathValue("id", "42").
+		Query("notify", "true").
+		JSON(map[string]any{"name": "widget"}).
+		Identity("user-1").
+		Do(httpx.NewHandler(update))
+
+	AssertStatus(t, rec, http.StatusOK)
+	AssertCode(t, rec, httpx.CodeOK)
+	res := MustData[*item](t, rec)
+	assert.Equal(t, 42, res.ID)
+	assert.Equal(t, "widget", res.Name)
+	assert.True(t, res.Notify)
+	assert.Equal(t, "user-1", res.Owner)
+}
+
+func TestRequestBuilder_Errors(t *testing.T) {
+	h := httpx.NewHandler(update)
+
+	rec := NewRequest("PUT", "/items/0").JSON(`{"name":"x"}`).Do(h)
+	AssertStatus(t, rec, http.StatusNotFound)
+	AssertCode(t, rec, httpx.CodeNotFound)
+	AssertHttpError(t, rec.Err(), http.StatusNotFound, httpx.CodeNotFound)
+
+	rec = NewRequest("PUT", "/items/1").PathValue("id", "1").JSON(`{}`).Do(h)
+	AssertHttpError(t, rec.Err(), http.StatusBadRequest, httpx.CodeValidation)
+
+	assert.Nil(t, NewRequest("PUT", "/").PathValue("id", "1").JSON(`{"name":"ok"}`).Do(h).Err())
+}
+
+func TestRequestBuilder_Router(t *testing.T) {
+	router := httpx.NewRouter()
+	router.Handle("PUT /items/{id}", httpx.NewHandler(update))
+	router.HandleFunc("GET /whoami", func(w http.ResponseWriter, r *http.Request) {
+		c, _ := httpx.GetCookie(r, "session")
+		w.Write([]byte(c + "|" + r.Header.Get("Authorization")))
+	})
+
+	rec := NewRequest("PUT", "/items/7").Form(url.Values{"name": {"gadget"}}).Do(router)
+	assert.Equal(t, "gadget", MustData[*item](t, rec).Name)
+
+	rec = NewRequest("GET", "/whoami").Cookie("__Host-session", "s1").BearerToken("tok").Do(router)
+	assert.Equal(t, "s1|Bearer tok", rec.Body.String())
+}
+
+func TestRequestBuilder_Multipart(t *testing.T) {
+	type uploadReq struct {
+		Title string                `form:"title"`
+		File  *multipart.FileHeader `form:"file"`
+	}
+	h := httpx.NewHandler(func(ctx context.Context, req *uploadReq) (string, error) {
+		f, err := req.File.Open()
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		data, _ := io.ReadAll(f)
+		return req.Title + ":" + req.File.Filename + ":" + string(data), nil
+	})
+
+	rec := NewRequest("POST", "/upload").
+		Multipart(map[string]string{"title": "report"}, File{Field: "file", Name: "a.txt", Content: []byte("hello")}).
+		Do(h)
+	assert.Equal(t, "report:a.txt:hello", MustData[string](t, rec))
+}
+
+func TestNewServer(t *testing.T) {
+	srv := NewServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	}))
+	resp, err := srv.Client().Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "pong", string(body))
+}
@@ -0,0 +1,99 @@
+package httpxtest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/oy3o/httpx"
+)
+
+// Recorder 包装 httptest.ResponseRecorder，提供 httpx 信封相关的解码能力。
+type Recorder struct {
+	*httptest.ResponseRecorder
+}
+
+// Decode 将响应体解码为 httpx.Response[T] 信封。
+func Decode[T any](rec *Recorder) (httpx.Response[T], error) {
+	var resp httpx.Response[T]
+	if err := sonic.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		return resp, fmt.Errorf("httpxtest: decode response %q: %w", rec.Body.String(), err)
+	}
+	return resp, nil
+}
+
+// MustData 断言响应成功 (2xx 且 code 为 OK) 并返回信封中的 Data。
+func MustData[T any](t testing.TB, rec *Recorder) T {
+	t.Helper()
+	resp, err := Decode[T](rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code < 200 || rec.Code >= 300 || resp.Code != httpx.CodeOK {
+		t.Fatalf("httpxtest: expected success, got status %d code %q message %q", rec.Code, resp.Code, resp.Message)
+	}
+	return resp.Data
+}
+
+// Err 将错误响应还原为 *httpx.HttpError；成功响应返回 nil。
+func (r *Recorder) Err() *httpx.HttpError {
+	if r.Code < 400 {
+		return nil
+	}
+	httpErr := &httpx.HttpError{HttpCode: r.Code}
+	if resp, err := Decode[any](r); err == nil {
+		httpErr.BizCode = resp.Code
+		httpErr.Msg = resp.Message
+	}
+	return httpErr
+}
+
+// AssertStatus 断言 HTTP 状态码
+func AssertStatus(t testing.TB, rec *Recorder, status int) {
+	t.Helper()
+	if rec.Code != status {
+		t.Errorf("httpxtest: expected status %d, got %d (body: %s)", status, rec.Code, rec.Body.String())
+	}
+}
+
+// AssertCode 断言响应信封中的业务码
+func AssertCode(t testing.TB, rec *Recorder, bizCode string) {
+	t.Helper()
+	resp, err := Decode[any](rec)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if resp.Code != bizCode {
+		t.Errorf("httpxtest: expected biz code %q, got %q (message: %q)", bizCode, resp.Code, resp.Message)
+	}
+}
+
+// AssertHttpError 断言 err 是 (或包装了) *httpx.HttpError，且状态码与业务码匹配。
+// bizCode 为空时不检查业务码。
+func AssertHttpError(t testing.TB, err error, status int, bizCode string) {
+	t.Helper()
+	var httpErr *httpx.HttpError
+	if !errors.As(err, &httpErr) {
+		t.Errorf("httpxtest: expected *httpx.HttpError, got %T: %v", err, err)
+		return
+	}
+	if httpErr.HttpCode != status {
+		t.Errorf("httpxtest: expected status %d, got %d", status, httpErr.HttpCode)
+	}
+	if bizCode != "" && httpErr.BizCode != bizCode {
+		t.Errorf("httpxtest: expected biz code %q, got %q", bizCode, httpErr.BizCode)
+	}
+}
+
+// NewServer 启动一个真实监听的测试服务器 (例如用于 client 包或 WebSocket)，测试结束时自动关闭。
+// 绝大多数场景下 RequestBuilder.Do 的进程内调用已经足够。
+func NewServer(t testing.TB, h http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
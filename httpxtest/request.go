@@ -0,0 +1,224 @@
+// Package httpxtest 提供测试 httpx 类型化 Handler 的工具，省去重复的
+// httptest.NewRequest + SetPathValue + JSON 解码样板代码。
+//
+//	rec := httpxtest.NewRequest("POST", "/users/{id}").
+//		PathValue("id", "42").
+//		JSON(map[string]any{"name": "alice"}).
+//		Identity("user-1").
+//		Do(httpx.NewHandler(UpdateUser))
+//
+//	httpxtest.AssertStatus(t, rec, http.StatusOK)
+//	user := httpxtest.MustData[*User](t, rec)
+package httpxtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/bytedance/sonic"
+	"github.com/oy3o/httpx"
+)
+
+// File 描述一个 multipart 文件字段
+type File struct {
+	Field       string
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+// RequestBuilder 以链式调用构造 *http.Request。
+// 构造过程中的错误 (如 JSON 编码失败) 会延迟到 Build 时 panic，测试中可以直接定位。
+type RequestBuilder struct {
+	method      string
+	target      string
+	ctx         context.Context
+	pathValues  [][2]string
+	query       url.Values
+	header      http.Header
+	cookies     []*http.Cookie
+	body        []byte
+	contentType string
+	identity    any
+	err         error
+}
+
+// NewRequest 创建请求构造器。target 可以包含 Query，如 "/search?q=go"。
+func NewRequest(method, target string) *RequestBuilder {
+	return &RequestBuilder{
+		method: method,
+		target: target,
+		query:  make(url.Values),
+		header: make(http.Header),
+	}
+}
+
+// Context 设置请求的基础 Context
+func (b *RequestBuilder) Context(ctx context.Context) *RequestBuilder {
+	b.ctx = ctx
+	return b
+}
+
+// PathValue 设置路径参数，相当于 r.SetPathValue。
+// 直接调用 Handler 而不经过 Router 时，用它模拟 ServeMux 的匹配结果。
+func (b *RequestBuilder) PathValue(name, value string) *RequestBuilder {
+	b.pathValues = append(b.pathValues, [2]string{name, value})
+	return b
+}
+
+// Query 追加一个 Query 参数
+func (b *RequestBuilder) Query(key, value string) *RequestBuilder {
+	b.query.Add(key, value)
+	return b
+}
+
+// Header 追加一个请求头
+func (b *RequestBuilder) Header(key, value string) *RequestBuilder {
+	b.header.Add(key, value)
+	return b
+}
+
+// Cookie 添加一个 Cookie
+func (b *RequestBuilder) Cookie(name, value string) *RequestBuilder {
+	b.cookies = append(b.cookies, &http.Cookie{Name: name, Value: value})
+	return b
+}
+
+// BearerToken 设置 Authorization: Bearer 头
+func (b *RequestBuilder) BearerToken(token string) *RequestBuilder {
+	b.header.Set("Authorization", "Bearer "+token)
+	return b
+}
+
+// Identity 直接向 Context 注入身份，绕过认证中间件，相当于 Auth 成功后的状态。
+func (b *RequestBuilder) Identity(identity any) *RequestBuilder {
+	b.identity = identity
+	return b
+}
+
+// JSON 将 v 编码为 JSON Body (string 和 []byte 原样使用)
+func (b *RequestBuilder) JSON(v any) *RequestBuilder {
+	switch body := v.(type) {
+	case string:
+		b.body = []byte(body)
+	case []byte:
+		b.body = body
+	default:
+		data, err := sonic.Marshal(v)
+		if err != nil {
+			b.err = fmt.Errorf("httpxtest: encode json: %w", err)
+			return b
+		}
+		b.body = data
+	}
+	b.contentType = "application/json"
+	return b
+}
+
+// Form 设置 application/x-www-form-urlencoded Body
+func (b *RequestBuilder) Form(values url.Values) *RequestBuilder {
+	b.body = []byte(values.Encode())
+	b.contentType = "application/x-www-form-urlencoded"
+	return b
+}
+
+// Multipart 设置 multipart/form-data Body
+func (b *RequestBuilder) Multipart(fields map[string]string, files ...File) *RequestBuilder {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			b.err = err
+			return b
+		}
+	}
+	for _, f := range files {
+		h := make(map[string][]string)
+		h["Content-Disposition"] = []string{
+			fmt.Sprintf(`form-data; name=%q; filename=%q`, f.Field, f.Name),
+		}
+		ct := f.ContentType
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+		h["Content-Type"] = []string{ct}
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			b.err = err
+			return b
+		}
+		if _, err := part.Write(f.Content); err != nil {
+			b.err = err
+			return b
+		}
+	}
+	if err := mw.Close(); err != nil {
+		b.err = err
+		return b
+	}
+	b.body = buf.Bytes()
+	b.contentType = mw.FormDataContentType()
+	return b
+}
+
+// Body 设置原始 Body 及其 Content-Type
+func (b *RequestBuilder) Body(contentType string, body []byte) *RequestBuilder {
+	b.body = body
+	b.contentType = contentType
+	return b
+}
+
+// Build 生成 *http.Request
+func (b *RequestBuilder) Build() *http.Request {
+	if b.err != nil {
+		panic(b.err)
+	}
+
+	var body io.Reader
+	if b.body != nil {
+		body = bytes.NewReader(b.body)
+	}
+	r := httptest.NewRequest(b.method, b.target, body)
+
+	if len(b.query) > 0 {
+		q := r.URL.Query()
+		for k, vs := range b.query {
+			q[k] = append(q[k], vs...)
+		}
+		r.URL.RawQuery = q.Encode()
+	}
+	for k, vs := range b.header {
+		r.Header[k] = append(r.Header[k], vs...)
+	}
+	if b.contentType != "" {
+		r.Header.Set("Content-Type", b.contentType)
+	}
+	for _, c := range b.cookies {
+		r.AddCookie(c)
+	}
+	for _, pv := range b.pathValues {
+		r.SetPathValue(pv[0], pv[1])
+	}
+
+	ctx := b.ctx
+	if ctx == nil {
+		ctx = r.Context()
+	}
+	if b.identity != nil {
+		ctx = context.WithValue(ctx, httpx.IdentityKey{}, b.identity)
+	}
+	return r.WithContext(ctx)
+}
+
+// Do 在进程内执行 Handler (可以是单个 Handler，也可以是完整的 Router) 并返回记录结果。
+func (b *RequestBuilder) Do(h http.Handler) *Recorder {
+	rec := &Recorder{ResponseRecorder: httptest.NewRecorder()}
+	h.ServeHTTP(rec, b.Build())
+	return rec
+}
@@ -0,0 +1,147 @@
+// Package urlenc 实现 httpx 与 httpx/client 共用的 URL 编码：
+// 按 ServeMux 模式填充路径通配符，以及将结构体字段格式化为 gorilla/schema 可解码的字符串。
+package urlenc
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ExpandPath 使用 values 填充 ServeMux 路径模式中的 {name} 与 {name...} 通配符，并返回用到的 key。
+// 值会被转义；{name...} 保留斜杠并逐段转义。只有 {name...} 允许空值。
+// "." 与 ".." (包括 {name...} 中的任一段) 会被拒绝：PathEscape 不转义它们，
+// 而 %2E 在浏览器和代理清理路径时同样被视为点段，生成的 URL 会逃出目标路由。
+func ExpandPath(pattern string, values map[string]string) (string, map[string]struct{}, error) {
+	used := make(map[string]struct{})
+	var sb strings.Builder
+	sb.Grow(len(pattern))
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			sb.WriteString(pattern)
+			return sb.String(), used, nil
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			return "", nil, fmt.Errorf("bad pattern %q", pattern)
+		}
+		end += start
+
+		sb.WriteString(pattern[:start])
+		name := pattern[start+1 : end]
+		pattern = pattern[end+1:]
+		if name == "$" {
+			// {$} 仅表示精确匹配结尾
+			continue
+		}
+
+		rest := strings.HasSuffix(name, "...")
+		name = strings.TrimSuffix(name, "...")
+		val, ok := values[name]
+		if !ok || (val == "" && !rest) {
+			return "", nil, fmt.Errorf("missing path value %q", name)
+		}
+		used[name] = struct{}{}
+
+		if rest {
+			segments := strings.Split(val, "/")
+			for i, seg := range segments {
+				if isDotSegment(seg) {
+					return "", nil, fmt.Errorf("path value %q contains a dot segment", name)
+				}
+				segments[i] = url.PathEscape(seg)
+			}
+			sb.WriteString(strings.Join(segments, "/"))
+		} else {
+			if isDotSegment(val) {
+				return "", nil, fmt.Errorf("path value %q is a dot segment", name)
+			}
+			sb.WriteString(url.PathEscape(val))
+		}
+	}
+}
+
+func isDotSegment(s string) bool {
+	return s == "." || s == ".."
+}
+
+// FieldByIndex 与 reflect.Value.FieldByIndex 类似，但遇到 nil 指针时返回 false 而不是 panic。
+func FieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+// FormatValues 将字段值格式化为字符串，与 gorilla/schema 的解码规则对称。
+// nil 指针返回 nil；除 []byte 以外的切片每个元素对应一个值。
+func FormatValues(v reflect.Value) ([]string, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		out := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s, err := formatValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	}
+	s, err := formatValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+func formatValue(v reflect.Value) (string, error) {
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
+		b, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// []byte 作为字符串传递 (对应 httpx.SchemaDecoder 注册的转换器)
+			return string(v.Bytes()), nil
+		}
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
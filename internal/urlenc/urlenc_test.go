@@ -0,0 +1,70 @@
+package urlenc
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		values  map[string]string
+		want    string
+		err     string
+	}{
+		{pattern: "/users/{id}", values: map[string]string{"id": "a/b c"}, want: "/users/a%2Fb%20c"},
+		{pattern: "/files/{path...}", values: map[string]string{"path": "a b/c.txt"}, want: "/files/a%20b/c.txt"},
+		{pattern: "/files/{path...}", values: map[string]string{"path": ""}, want: "/files/"},
+		{pattern: "/{$}", want: "/"},
+		{pattern: "/users/{id}", values: map[string]string{"id": ""}, err: `missing path value "id"`},
+		{pattern: "/users/{id", err: "bad pattern"},
+		{pattern: "/users/{id}/posts", values: map[string]string{"id": ".."}, err: `path value "id" is a dot segment`},
+		{pattern: "/users/{id}", values: map[string]string{"id": "."}, err: `path value "id" is a dot segment`},
+		{pattern: "/files/{path...}", values: map[string]string{"path": "a/../../etc/passwd"}, err: `path value "path" contains a dot segment`},
+		{pattern: "/files/{path...}", values: map[string]string{"path": "./a"}, err: `path value "path" contains a dot segment`},
+		{pattern: "/files/{path...}", values: map[string]string{"path": "a/..b/.c"}, want: "/files/a/..b/.c"},
+	}
+	for _, tt := range tests {
+		got, used, err := ExpandPath(tt.pattern, tt.values)
+		if tt.err != "" {
+			assert.ErrorContains(t, err, tt.err, tt.pattern)
+			continue
+		}
+		require.NoError(t, err, tt.pattern)
+		assert.Equal(t, tt.want, got)
+		assert.Len(t, used, len(tt.values))
+	}
+}
+
+func TestFormatValues(t *testing.T) {
+	type nested struct{ N int }
+	type req struct {
+		S    string
+		I    *int
+		F    float32
+		List []uint
+		Addr netip.Addr
+		Raw  []byte
+		*nested
+	}
+	one := 1
+	v := reflect.ValueOf(req{S: "s", I: &one, F: 1.5, List: []uint{1, 2}, Addr: netip.MustParseAddr("::1"), Raw: []byte("a b")})
+
+	for i, want := range [][]string{{"s"}, {"1"}, {"1.5"}, {"1", "2"}, {"::1"}, {"a b"}} {
+		got, err := FormatValues(v.Field(i))
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, ok := FieldByIndex(v, []int{6, 0})
+	assert.False(t, ok, "nil embedded pointer")
+	got, err := FormatValues(reflect.ValueOf((*int)(nil)))
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	_, err = FormatValues(reflect.ValueOf(map[string]int{}))
+	assert.ErrorContains(t, err, "unsupported type")
+}
@@ -1,8 +1,14 @@
 package httpx
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"runtime"
+	"runtime/debug"
 
 	"github.com/felixge/httpsnoop"
 )
@@ -18,20 +24,146 @@ func Chain(h http.Handler, mws ...Middleware) http.Handler {
 	return h
 }
 
-// Recovery 捕获 Panic 防止服务崩溃
+// PanicKind 对 panic 的值进行分类，便于日志和指标区分“代码缺陷”与“主动抛出”。
+type PanicKind string
+
+const (
+	// PanicKindRuntime 运行时错误，如空指针解引用、数组越界 (runtime.Error)
+	PanicKindRuntime PanicKind = "runtime"
+	// PanicKindError 以 error 值触发的 panic
+	PanicKindError PanicKind = "error"
+	// PanicKindValue 以非 error 值 (如字符串) 触发的 panic
+	PanicKindValue PanicKind = "value"
+)
+
+// PanicError 是 Recovery 捕获到的 panic，携带原始值和调用栈。
+// 它实现了 StackTrace() []byte，LogErrorHook 等 ErrorHook 可以据此输出调用栈。
+// 如果原始值是 error，可以通过 errors.Is/As 访问。
+type PanicError struct {
+	Value any
+	Kind  PanicKind
+	Stack []byte
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("panic: %v", e.Value) }
+
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+func (e *PanicError) StackTrace() []byte { return e.Stack }
+
+// HTTPStatus 沿用原始 error 的状态码 (例如 panic(httpx.ErrNotFound))，否则为 500。
+func (e *PanicError) HTTPStatus() int {
+	var coder ErrorCoder
+	if errors.As(e.Unwrap(), &coder) {
+		return coder.HTTPStatus()
+	}
+	return http.StatusInternalServerError
+}
+
+// BizStatus 沿用原始 error 的业务码。
+func (e *PanicError) BizStatus() string {
+	var coder BizCoder
+	if errors.As(e.Unwrap(), &coder) {
+		return coder.BizStatus()
+	}
+	return ""
+}
+
+// PublicMessage 沿用原始 error 的安全消息，panic 本身的内容永远不直接暴露。
+func (e *PanicError) PublicMessage() string {
+	var pub PublicError
+	if errors.As(e.Unwrap(), &pub) {
+		return pub.PublicMessage()
+	}
+	return ""
+}
+
+func newPanicError(val any) *PanicError {
+	kind := PanicKindValue
+	if _, ok := val.(runtime.Error); ok {
+		kind = PanicKindRuntime
+	} else if _, ok := val.(error); ok {
+		kind = PanicKindError
+	}
+	return &PanicError{Value: val, Kind: kind, Stack: debug.Stack()}
+}
+
+// Recovery 捕获 Panic 防止服务崩溃。
+//   - panic 被包装为 *PanicError (携带调用栈) 后交给 Error 处理，ErrorHook 可以记录完整调用栈。
+//   - http.ErrAbortHandler 是标准库用于主动中止响应的信号，会被原样重新抛出。
+//   - 如果响应头已经写出，不会再写第二个状态码，而是记录错误后以 http.ErrAbortHandler 中止连接，
+//     避免客户端把被截断的响应当成完整响应。
+//   - 可通过 WithPanicHook 将 panic 接入指标等观测系统。
 func Recovery(opts ...ErrorOption) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			written := false
+			ww := httpsnoop.Wrap(w, httpsnoop.Hooks{
+				WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+					return func(code int) {
+						// 1xx 信息性响应 (如 103 Early Hints) 之后仍然可以写最终状态码
+						if code >= 200 {
+							written = true
+						}
+						next(code)
+					}
+				},
+				Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+					return func(b []byte) (int, error) {
+						written = true
+						return next(b)
+					}
+				},
+				ReadFrom: func(next httpsnoop.ReadFromFunc) httpsnoop.ReadFromFunc {
+					return func(src io.Reader) (int64, error) {
+						written = true
+						return next(src)
+					}
+				},
+				Flush: func(next httpsnoop.FlushFunc) httpsnoop.FlushFunc {
+					return func() {
+						written = true
+						next()
+					}
+				},
+				Hijack: func(next httpsnoop.HijackFunc) httpsnoop.HijackFunc {
+					return func() (net.Conn, *bufio.ReadWriter, error) {
+						written = true
+						return next()
+					}
+				},
+			})
+
 			defer func() {
-				if val := recover(); val != nil {
-					if err, ok := val.(error); ok {
-						Error(w, r, err, opts...)
-					} else {
-						Error(w, r, fmt.Errorf("panic: %v", val), opts...)
+				val := recover()
+				if val == nil {
+					return
+				}
+				if err, ok := val.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(val)
+				}
+
+				perr := newPanicError(val)
+				cfg := errorConfig{hook: ErrorHook}
+				for _, opt := range opts {
+					opt(&cfg)
+				}
+				if cfg.panicHook != nil {
+					cfg.panicHook(r, perr)
+				}
+
+				if written {
+					if cfg.hook != nil {
+						cfg.hook(r.Context(), perr)
 					}
+					panic(http.ErrAbortHandler)
 				}
+				Error(w, r, perr, opts...)
 			}()
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(ww, r)
 		})
 	}
 }
@@ -0,0 +1,220 @@
+package httpx
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+)
+
+// RedactedValue 是被脱敏字段在日志中的占位符
+const RedactedValue = "[REDACTED]"
+
+// 默认脱敏的 Header 和 Query 参数，覆盖最常见的凭证载体。
+var (
+	DefaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+	DefaultRedactQuery   = []string{"token", "access_token", "refresh_token", "id_token", "code", "client_secret", "api_key"}
+)
+
+// SubjectHolder 由身份类型实现，返回写入日志的稳定标识 (如 Subject、Key ID)。
+// 方法名刻意避开 Subject，以免与 Claims 中常见的同名字段冲突。
+type SubjectHolder interface {
+	IdentitySubject() string
+}
+
+// AccessLogOptions 定义访问日志配置
+type AccessLogOptions struct {
+	// Message 日志消息，默认为 "http request"
+	Message string
+
+	// SampleRate 成功请求 (status < 400 且未超时) 的采样率，取值 (0, 1]。
+	// 0 表示不采样 (全部记录)，负数表示不记录成功请求。
+	// 错误请求和慢请求总是会被记录。
+	SampleRate float64
+
+	// SlowThreshold 超过该耗时的请求视为慢请求，以 Warn 级别记录。0 表示禁用。
+	SlowThreshold time.Duration
+
+	// LogHeaders 需要记录的请求头
+	LogHeaders []string
+
+	// RedactHeaders 需要脱敏的请求头，默认为 DefaultRedactHeaders
+	RedactHeaders []string
+
+	// RedactQuery 需要脱敏的 Query 参数 (大小写不敏感)，默认为 DefaultRedactQuery
+	RedactQuery []string
+}
+
+// AccessLog 返回一个基于 log/slog 的访问日志中间件。
+// 它记录 method, route (路由模式), status, bytes, duration, client_ip, identity, trace_id 和业务码。
+//   - 5xx 使用 Error 级别，4xx 和慢请求使用 Warn 级别，其余使用 Info 级别。
+//   - client_ip 依赖 NewClientIPMiddleware，identity 依赖 Auth，二者放在本中间件内层也能被记录。
+//   - identity 只记录稳定的标识 (参见 identityLogValue)，不会输出身份结构体本身。
+//
+// logger 为 nil 时使用 slog.Default()。
+func AccessLog(logger *slog.Logger, opts ...AccessLogOptions) Middleware {
+	var opt AccessLogOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Message == "" {
+		opt.Message = "http request"
+	}
+	if opt.RedactHeaders == nil {
+		opt.RedactHeaders = DefaultRedactHeaders
+	}
+	if opt.RedactQuery == nil {
+		opt.RedactQuery = DefaultRedactQuery
+	}
+
+	// 预先规范化，避免每次请求重复计算
+	redactHeaders := make(map[string]struct{}, len(opt.RedactHeaders))
+	for _, h := range opt.RedactHeaders {
+		redactHeaders[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	redactQuery := make(map[string]struct{}, len(opt.RedactQuery))
+	for _, q := range opt.RedactQuery {
+		redactQuery[strings.ToLower(q)] = struct{}{}
+	}
+	logHeaders := make([]string, len(opt.LogHeaders))
+	for i, h := range opt.LogHeaders {
+		logHeaders[i] = http.CanonicalHeaderKey(h)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, state := withRequestState(r)
+
+			m := httpsnoop.CaptureMetrics(next, w, r)
+
+			// 决定日志级别与是否采样
+			level := slog.LevelInfo
+			slow := opt.SlowThreshold > 0 && m.Duration >= opt.SlowThreshold
+			switch {
+			case m.Code >= 500:
+				level = slog.LevelError
+			case m.Code >= 400 || slow:
+				level = slog.LevelWarn
+			case opt.SampleRate < 0:
+				return
+			case opt.SampleRate > 0 && opt.SampleRate < 1 && rand.Float64() >= opt.SampleRate:
+				return
+			}
+
+			l := logger
+			if l == nil {
+				l = slog.Default()
+			}
+			ctx := r.Context()
+			if !l.Enabled(ctx, level) {
+				return
+			}
+
+			attrs := make([]slog.Attr, 0, 14)
+			attrs = append(attrs,
+				slog.String("method", r.Method),
+				slog.String("route", routeOf(r, state)),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", m.Code),
+				slog.Int64("bytes", m.Written),
+				slog.Duration("duration", m.Duration),
+			)
+			if r.URL.RawQuery != "" {
+				attrs = append(attrs, slog.String("query", redactRawQuery(r.URL.RawQuery, redactQuery)))
+			}
+			ip := state.clientIP
+			if ip == "" {
+				ip = ClientIP(ctx)
+			}
+			if ip != "" {
+				attrs = append(attrs, slog.String("client_ip", ip))
+			}
+			if id, ok := identityLogValue(state.identity); ok {
+				attrs = append(attrs, slog.String("identity", id))
+			}
+			if GetTraceID != nil {
+				if traceID := GetTraceID(ctx); traceID != "" {
+					attrs = append(attrs, slog.String("trace_id", traceID))
+				}
+			}
+			if state.bizCode != "" {
+				attrs = append(attrs, slog.String("code", state.bizCode))
+			}
+			if slow {
+				attrs = append(attrs, slog.Bool("slow", true))
+			}
+			if len(logHeaders) > 0 {
+				headers := make([]any, 0, len(logHeaders))
+				for _, k := range logHeaders {
+					v := r.Header[k]
+					if len(v) == 0 {
+						continue
+					}
+					if _, ok := redactHeaders[k]; ok {
+						headers = append(headers, slog.String(k, RedactedValue))
+					} else {
+						headers = append(headers, slog.String(k, strings.Join(v, ", ")))
+					}
+				}
+				if len(headers) > 0 {
+					attrs = append(attrs, slog.Group("headers", headers...))
+				}
+			}
+
+			l.LogAttrs(ctx, level, opt.Message, attrs...)
+		})
+	}
+}
+
+// redactRawQuery 替换敏感参数的值，保持参数顺序和其余参数原样。
+func redactRawQuery(rawQuery string, redact map[string]struct{}) string {
+	if len(redact) == 0 {
+		return rawQuery
+	}
+	var sb strings.Builder
+	sb.Grow(len(rawQuery))
+	for i, pair := range strings.Split(rawQuery, "&") {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		key, _, hasValue := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if _, ok := redact[strings.ToLower(name)]; ok && hasValue {
+			sb.WriteString(key)
+			sb.WriteByte('=')
+			sb.WriteString(RedactedValue)
+			continue
+		}
+		sb.WriteString(pair)
+	}
+	return sb.String()
+}
+
+// identityLogValue 返回身份在日志中的标识：
+//   - 实现了 SubjectHolder 的身份使用 IdentitySubject()；
+//   - 字符串身份原样记录；
+//   - 其余身份只记录其类型名 (如 "*main.User")，以免把凭证哈希、完整的 Claims 等写入日志。
+//
+// 返回空标识时不记录 identity 字段。
+func identityLogValue(identity any) (string, bool) {
+	var id string
+	switch v := identity.(type) {
+	case nil:
+		return "", false
+	case SubjectHolder:
+		id = v.IdentitySubject()
+	case string:
+		id = v
+	default:
+		id = fmt.Sprintf("%T", v)
+	}
+	return id, id != ""
+}
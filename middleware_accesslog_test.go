@@ -0,0 +1,161 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var m map[string]any
+		require.NoError(t, sonic.Unmarshal(line, &m))
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestAccessLog_Fields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	router := NewRouter()
+	router.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	auth := Auth(func(w http.ResponseWriter, r *http.Request) (any, error) {
+		return "user-1", nil
+	})
+	h := Chain(router,
+		AccessLog(logger, AccessLogOptions{LogHeaders: []string{"authorization", "User-Agent"}}),
+		NewClientIPMiddleware(nil),
+		auth,
+	)
+
+	r := httptest.NewRequest("GET", "/orders/42?token=secret&page=2", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("User-Agent", "test")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := decodeLogLines(t, &buf)
+	require.Len(t, lines, 1)
+	line := lines[0]
+
+	assert.Equal(t, "INFO", line["level"])
+	assert.Equal(t, "GET", line["method"])
+	assert.Equal(t, "GET /orders/{id}", line["route"])
+	assert.Equal(t, float64(200), line["status"])
+	assert.Equal(t, float64(2), line["bytes"])
+	assert.Equal(t, "192.0.2.1", line["client_ip"])
+	assert.Equal(t, "user-1", line["identity"])
+	assert.Equal(t, "token=[REDACTED]&page=2", line["query"])
+
+	headers := line["headers"].(map[string]any)
+	assert.Equal(t, RedactedValue, headers["Authorization"])
+	assert.Equal(t, "test", headers["User-Agent"])
+	assert.NotContains(t, buf.String(), "secret")
+}
+
+type testSubjectIdentity struct {
+	ID    string
+	Email string
+}
+
+func (u *testSubjectIdentity) IdentitySubject() string { return u.ID }
+
+func TestAccessLog_IdentitySubjectOnly(t *testing.T) {
+	type opaque struct{ Secret string }
+
+	tests := []struct {
+		name     string
+		identity any
+		want     any
+	}{
+		{"string", "user-1", "user-1"},
+		{"subject holder", &testSubjectIdentity{ID: "user-9", Email: "a@example.com"}, "user-9"},
+		{"empty subject", &testSubjectIdentity{Email: "a@example.com"}, nil},
+		{"unknown struct", &opaque{Secret: "hunter2"}, "*httpx.opaque"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, nil))
+			h := Chain(http.NotFoundHandler(),
+				AccessLog(logger),
+				Auth(func(w http.ResponseWriter, r *http.Request) (any, error) { return tt.identity, nil }),
+			)
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+			lines := decodeLogLines(t, &buf)
+			require.Len(t, lines, 1)
+			assert.Equal(t, tt.want, lines[0]["identity"])
+			assert.NotContains(t, buf.String(), "hunter2")
+			assert.NotContains(t, buf.String(), "example.com")
+		})
+	}
+}
+
+func TestAccessLog_SamplingAndErrors(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	h := AccessLog(logger, AccessLogOptions{SampleRate: -1, SlowThreshold: 20 * time.Millisecond})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/fail":
+				Error(w, r, ErrNotFound)
+			case "/slow":
+				time.Sleep(25 * time.Millisecond)
+			}
+		}),
+	)
+
+	// 成功请求不被记录
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ok", nil))
+	assert.Empty(t, buf.String())
+
+	// 错误请求总是记录，并带上业务码
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fail", nil))
+	// 慢请求总是记录
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+
+	lines := decodeLogLines(t, &buf)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "WARN", lines[0]["level"])
+	assert.Equal(t, CodeNotFound, lines[0]["code"])
+	assert.Equal(t, "WARN", lines[1]["level"])
+	assert.Equal(t, true, lines[1]["slow"])
+}
+
+func TestAccessLog_TraceID(t *testing.T) {
+	original := GetTraceID
+	GetTraceID = func(ctx context.Context) string { return "trace-abc" }
+	defer func() { GetTraceID = original }()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	h := AccessLog(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Error(w, r, ErrInternal)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", nil))
+
+	lines := decodeLogLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "ERROR", lines[0]["level"])
+	assert.Equal(t, "trace-abc", lines[0]["trace_id"])
+	assert.Equal(t, CodeInternalError, lines[0]["code"])
+}
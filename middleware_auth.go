@@ -21,8 +21,25 @@ func GetIdentity(ctx context.Context) any {
 	return ctx.Value(IdentityKey{})
 }
 
+// IdentityAs 以具体类型 T 获取身份信息，未认证或类型不符时返回 (零值, false)。
+//
+//	claims, ok := httpx.IdentityAs[*MyClaims](ctx)
+func IdentityAs[T any](ctx context.Context) (T, bool) {
+	identity, ok := ctx.Value(IdentityKey{}).(T)
+	return identity, ok
+}
+
+// AuthErrorFrom 获取 Auth 中间件记录的非 HttpError 认证错误 (如凭证校验失败的原因)，没有时返回 nil。
+func AuthErrorFrom(ctx context.Context) error {
+	err, _ := ctx.Value(AuthErrorKey{}).(error)
+	return err
+}
+
+// GetAuthError 等同于 AuthErrorFrom。
+//
+// Deprecated: 使用 AuthErrorFrom。
 func GetAuthError(ctx context.Context) error {
-	return ctx.Value(AuthErrorKey{}).(error)
+	return AuthErrorFrom(ctx)
 }
 
 // AuthStrategy 定义从请求中提取身份的原子策略。
@@ -58,6 +75,7 @@ func Auth(strategy AuthStrategy, Errors ...ErrorFunc) Middleware {
 			if identity != nil {
 				ctx := context.WithValue(r.Context(), IdentityKey{}, identity)
 				r = r.WithContext(ctx)
+				recordIdentity(ctx, identity)
 			}
 
 			next.ServeHTTP(w, r)
@@ -0,0 +1,173 @@
+package httpx
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// API Key 验证失败的错误
+var (
+	ErrInvalidAPIKey = &HttpError{HttpCode: http.StatusUnauthorized, BizCode: CodeUnauthorized, Msg: "invalid API key"}
+	ErrAPIKeyExpired = &HttpError{HttpCode: http.StatusUnauthorized, BizCode: CodeUnauthorized, Msg: "API key expired"}
+	ErrAPIKeyScope   = &HttpError{HttpCode: http.StatusForbidden, BizCode: CodeForbidden, Msg: "API key lacks required scope"}
+)
+
+// APIKey 是 API Key 的存储记录。
+// 完整的 Key 形如 "<Prefix>.<Secret>"：Prefix 是公开的查找索引，Secret 只以 SHA-256 哈希形式保存。
+// 验证成功后 *APIKey 会作为身份注入 Context。
+type APIKey struct {
+	// Prefix 公开的 Key 标识，用于在 Store 中查找。
+	Prefix string
+	// Hash 是 Secret 的 SHA-256 哈希 (参见 HashAPIKeySecret)。
+	Hash []byte
+	// Owner 标识 Key 的持有者，如合作方 ID。
+	Owner string
+	// Scopes Key 被授予的权限范围。
+	Scopes []string
+	// ExpiresAt 过期时间，零值表示永不过期。
+	ExpiresAt time.Time
+}
+
+// HasScope 判断 Key 是否被授予了 scope。
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// IdentitySubject 实现 SubjectHolder，日志中只记录公开的 Prefix，不会泄露 Hash。
+func (k *APIKey) IdentitySubject() string {
+	return k.Prefix
+}
+
+// HashAPIKeySecret 计算 Secret 的存储哈希。
+// API Key 本身是高熵随机串，因此无需使用 bcrypt 之类的慢哈希。
+func HashAPIKeySecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+// GenerateAPIKey 生成一个新的 API Key。
+// 返回的 plaintext 只应展示给用户一次；record 中仅包含哈希，可直接保存到 Store。
+func GenerateAPIKey(owner string, scopes ...string) (plaintext string, record *APIKey, err error) {
+	var buf [8 + 32]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", nil, err
+	}
+	prefix := hex.EncodeToString(buf[:8])
+	secret := base64.RawURLEncoding.EncodeToString(buf[8:])
+	return prefix + "." + secret, &APIKey{
+		Prefix: prefix,
+		Hash:   HashAPIKeySecret(secret),
+		Owner:  owner,
+		Scopes: scopes,
+	}, nil
+}
+
+// APIKeyStore 按前缀查找 API Key。
+// 不存在时应返回 (nil, nil)；返回的 error 仅用于表示存储故障。
+type APIKeyStore interface {
+	LookupAPIKey(ctx context.Context, prefix string) (*APIKey, error)
+}
+
+// MemoryAPIKeyStore 是基于内存的 APIKeyStore，适合测试或 Key 数量较少的场景。
+type MemoryAPIKeyStore struct {
+	keys *xsync.Map[string, *APIKey]
+}
+
+// NewMemoryAPIKeyStore 创建一个内存 Store，并预先添加 keys。
+func NewMemoryAPIKeyStore(keys ...*APIKey) *MemoryAPIKeyStore {
+	s := &MemoryAPIKeyStore{keys: xsync.NewMap[string, *APIKey]()}
+	for _, k := range keys {
+		s.Add(k)
+	}
+	return s
+}
+
+// Add 添加或替换一个 Key。
+func (s *MemoryAPIKeyStore) Add(key *APIKey) { s.keys.Store(key.Prefix, key) }
+
+// Revoke 吊销一个 Key。
+func (s *MemoryAPIKeyStore) Revoke(prefix string) { s.keys.Delete(prefix) }
+
+func (s *MemoryAPIKeyStore) LookupAPIKey(ctx context.Context, prefix string) (*APIKey, error) {
+	key, _ := s.keys.Load(prefix)
+	return key, nil
+}
+
+// APIKeyOptions 配置 API Key 认证策略。
+type APIKeyOptions struct {
+	// Store Key 存储。必填。
+	Store APIKeyStore
+
+	// Header 读取 Key 的请求头。默认 "X-API-Key"。
+	Header string
+
+	// Query 非空时，在请求头缺失的情况下从该 Query 参数读取 Key。
+	// 注意 URL 容易出现在日志与 Referer 中，仅在客户端无法设置请求头时使用。
+	Query string
+
+	// Scopes 要求 Key 必须拥有的全部权限范围，缺少任何一个返回 403。
+	Scopes []string
+}
+
+// APIKeyAuth 创建一个 API Key 认证策略。
+// 未携带 Key 时返回 ErrNoCredentials，可与其他策略通过 AuthChain 组合；
+// Key 无效或过期时返回 401，权限范围不足时返回 403。验证成功后以 *APIKey 作为身份。
+func APIKeyAuth(opts APIKeyOptions) AuthStrategy {
+	if opts.Store == nil {
+		panic("httpx: APIKeyOptions.Store is required")
+	}
+	if opts.Header == "" {
+		opts.Header = "X-API-Key"
+	}
+	header := http.CanonicalHeaderKey(opts.Header)
+
+	return func(w http.ResponseWriter, r *http.Request) (any, error) {
+		var raw string
+		if v := r.Header[header]; len(v) > 0 {
+			raw = v[0]
+		}
+		if raw == "" && opts.Query != "" {
+			raw = r.URL.Query().Get(opts.Query)
+		}
+		if raw == "" {
+			return nil, ErrNoCredentials
+		}
+
+		prefix, secret, ok := strings.Cut(raw, ".")
+		if !ok || prefix == "" || secret == "" {
+			return nil, ErrInvalidAPIKey
+		}
+		key, err := opts.Store.LookupAPIKey(r.Context(), prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		// 即使 Key 不存在也计算一次哈希，使两条路径的耗时接近
+		hash := HashAPIKeySecret(secret)
+		if key == nil {
+			return nil, ErrInvalidAPIKey
+		}
+		if subtle.ConstantTimeCompare(hash, key.Hash) != 1 {
+			return nil, ErrInvalidAPIKey
+		}
+		if !key.ExpiresAt.IsZero() && !time.Now().Before(key.ExpiresAt) {
+			return nil, ErrAPIKeyExpired
+		}
+		for _, scope := range opts.Scopes {
+			if !key.HasScope(scope) {
+				return nil, ErrAPIKeyScope
+			}
+		}
+		return key, nil
+	}
+}
@@ -0,0 +1,107 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingAPIKeyStore struct{}
+
+func (failingAPIKeyStore) LookupAPIKey(ctx context.Context, prefix string) (*APIKey, error) {
+	return nil, errors.New("store unavailable")
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	plain, record, err := GenerateAPIKey("partner-1", "orders:read", "orders:write")
+	require.NoError(t, err)
+	prefix, _, _ := strings.Cut(plain, ".")
+	assert.Equal(t, prefix, record.Prefix)
+	assert.NotContains(t, string(record.Hash), plain)
+
+	expiredPlain, expired, _ := GenerateAPIKey("partner-2")
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+	store := NewMemoryAPIKeyStore(record, expired)
+	strategy := APIKeyAuth(APIKeyOptions{Store: store, Query: "api_key", Scopes: []string{"orders:read"}})
+
+	tests := []struct {
+		name    string
+		setup   func(r *http.Request)
+		owner   string
+		wantErr error
+	}{
+		{name: "Header", setup: func(r *http.Request) { r.Header.Set("X-API-Key", plain) }, owner: "partner-1"},
+		{name: "Query", setup: func(r *http.Request) { r.URL.RawQuery = "api_key=" + plain }, owner: "partner-1"},
+		{name: "Missing", setup: func(r *http.Request) {}, wantErr: ErrNoCredentials},
+		{name: "Malformed", setup: func(r *http.Request) { r.Header.Set("X-API-Key", "nodot") }, wantErr: ErrInvalidAPIKey},
+		{name: "UnknownPrefix", setup: func(r *http.Request) { r.Header.Set("X-API-Key", "deadbeef.secret") }, wantErr: ErrInvalidAPIKey},
+		{name: "WrongSecret", setup: func(r *http.Request) { r.Header.Set("X-API-Key", record.Prefix+".wrong") }, wantErr: ErrInvalidAPIKey},
+		{name: "Expired", setup: func(r *http.Request) { r.Header.Set("X-API-Key", expiredPlain) }, wantErr: ErrAPIKeyExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			tt.setup(req)
+			identity, err := strategy(httptest.NewRecorder(), req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			key := identity.(*APIKey)
+			assert.Equal(t, tt.owner, key.Owner)
+			assert.Equal(t, record.Prefix, key.IdentitySubject())
+			assert.True(t, key.HasScope("orders:write"))
+		})
+	}
+
+	t.Run("Scope", func(t *testing.T) {
+		admin := APIKeyAuth(APIKeyOptions{Store: store, Scopes: []string{"admin"}})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", plain)
+		_, err := admin(nil, req)
+		assert.ErrorIs(t, err, ErrAPIKeyScope)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		store.Revoke(record.Prefix)
+		defer store.Add(record)
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", plain)
+		_, err := strategy(nil, req)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	})
+
+	t.Run("StoreFailure", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", plain)
+		_, err := APIKeyAuth(APIKeyOptions{Store: failingAPIKeyStore{}})(nil, req)
+		assert.EqualError(t, err, "store unavailable")
+	})
+
+	t.Run("AuthChain", func(t *testing.T) {
+		chain := AuthChain(FromHeader("Bearer", mockValidator("tok", "user-1")), strategy)
+		handler := Auth(chain)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(GetIdentity(r.Context()).(*APIKey).Owner))
+		}))
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", plain)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, "partner-1", w.Body.String())
+
+		req.Header.Set("X-API-Key", record.Prefix+".wrong")
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
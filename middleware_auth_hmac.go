@@ -0,0 +1,332 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// HMAC 签名验证失败的错误
+var (
+	ErrSignatureMissing = &HttpError{HttpCode: http.StatusUnauthorized, BizCode: CodeUnauthorized, Msg: "request signature required"}
+	ErrSignatureInvalid = &HttpError{HttpCode: http.StatusUnauthorized, BizCode: CodeUnauthorized, Msg: "invalid request signature"}
+	ErrSignatureExpired = &HttpError{HttpCode: http.StatusUnauthorized, BizCode: CodeUnauthorized, Msg: "request timestamp outside the allowed window"}
+	ErrNonceReplayed    = &HttpError{HttpCode: http.StatusUnauthorized, BizCode: CodeUnauthorized, Msg: "request nonce already used"}
+)
+
+// NonceCache 记录已使用过的 nonce，用于防重放。
+// Use 在 nonce 首次出现时记录并返回 true，重复出现时返回 false。
+// expiresAt 之后该 nonce 可以被遗忘 (此时时间戳校验已经能拒绝它)。
+type NonceCache interface {
+	Use(ctx context.Context, nonce string, expiresAt time.Time) (bool, error)
+}
+
+// MemoryNonceCache 是基于内存的 NonceCache，仅适用于单实例部署。
+type MemoryNonceCache struct {
+	nonces *xsync.Map[string, time.Time]
+	ops    atomic.Uint64
+}
+
+// NewMemoryNonceCache 创建一个内存 NonceCache。
+func NewMemoryNonceCache() *MemoryNonceCache {
+	return &MemoryNonceCache{nonces: xsync.NewMap[string, time.Time]()}
+}
+
+func (c *MemoryNonceCache) Use(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	now := time.Now()
+	// 每 1024 次写入顺带清理一次过期的 nonce，避免无限增长
+	if c.ops.Add(1)%1024 == 0 {
+		c.nonces.Range(func(k string, exp time.Time) bool {
+			if now.After(exp) {
+				c.nonces.Delete(k)
+			}
+			return true
+		})
+	}
+
+	fresh := false
+	c.nonces.Compute(nonce, func(old time.Time, loaded bool) (time.Time, xsync.ComputeOp) {
+		if loaded && now.Before(old) {
+			return old, xsync.CancelOp
+		}
+		fresh = true
+		return expiresAt, xsync.UpdateOp
+	})
+	return fresh, nil
+}
+
+// HMACOptions 配置 HMAC 请求签名。
+//
+// 签名为 hex(HMAC-SHA256(key, canonical))，其中 canonical 由以下各行以 "\n" 连接而成：
+//
+//	METHOD
+//	/escaped/path?raw_query
+//	timestamp (Unix 秒)
+//	nonce (可为空)
+//	每个 Headers 中的请求头一行: lowercase-name:value
+//	hex(SHA-256(body))
+type HMACOptions struct {
+	// Keys 按 key ID 查找密钥 ([]byte)，如 StaticKeys{"2024-01": secret}。
+	// 通过同时保留新旧 key ID 实现密钥轮换。必填。
+	Keys KeySet
+
+	// Headers 参与签名的请求头，如 "Content-Type"。
+	Headers []string
+
+	// MaxSkew 允许的时间戳偏差 (双向)。默认 5 分钟。
+	MaxSkew time.Duration
+
+	// Nonces 非 nil 时要求请求携带 nonce，并拒绝重复的 nonce。
+	Nonces NonceCache
+
+	// MaxBodySize 为计算摘要而缓冲的请求体上限，超过时返回 413。默认 2MB (与 NewHandler 一致)。
+	MaxBodySize int64
+
+	// 各签名参数所在的请求头，默认分别为 X-Key-Id、X-Timestamp、X-Nonce、X-Signature。
+	KeyIDHeader     string
+	TimestampHeader string
+	NonceHeader     string
+	SignatureHeader string
+}
+
+func (o *HMACOptions) setDefaults() {
+	if o.MaxSkew <= 0 {
+		o.MaxSkew = 5 * time.Minute
+	}
+	if o.MaxBodySize <= 0 {
+		o.MaxBodySize = 2 << 20
+	}
+	if o.KeyIDHeader == "" {
+		o.KeyIDHeader = "X-Key-Id"
+	}
+	if o.TimestampHeader == "" {
+		o.TimestampHeader = "X-Timestamp"
+	}
+	if o.NonceHeader == "" {
+		o.NonceHeader = "X-Nonce"
+	}
+	if o.SignatureHeader == "" {
+		o.SignatureHeader = "X-Signature"
+	}
+}
+
+// HMACIdentity 是 HMAC 签名验证通过后注入的身份。
+type HMACIdentity struct {
+	KeyID     string
+	Timestamp time.Time
+	Nonce     string
+}
+
+// IdentitySubject 实现 SubjectHolder，日志中只记录 KeyID。
+func (id HMACIdentity) IdentitySubject() string {
+	return id.KeyID
+}
+
+// HMACAuth 创建一个验证 HMAC 请求签名的认证策略。
+// 请求未携带签名时返回 ErrNoCredentials，可与其他策略通过 AuthChain 组合。
+// 请求体会在 MaxBodySize 内被完整读取用于计算摘要，之后恢复，后续的 Binder 可以照常读取。
+func HMACAuth(opts HMACOptions) AuthStrategy {
+	if opts.Keys == nil {
+		panic("httpx: HMACOptions.Keys is required")
+	}
+	opts.setDefaults()
+
+	return func(w http.ResponseWriter, r *http.Request) (any, error) {
+		sig := r.Header.Get(opts.SignatureHeader)
+		if sig == "" {
+			return nil, ErrNoCredentials
+		}
+		keyID := r.Header.Get(opts.KeyIDHeader)
+		ts := r.Header.Get(opts.TimestampHeader)
+		nonce := r.Header.Get(opts.NonceHeader)
+		if keyID == "" || ts == "" || (opts.Nonces != nil && nonce == "") {
+			return nil, ErrSignatureInvalid
+		}
+
+		// 1. 时间戳窗口
+		sec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return nil, ErrSignatureInvalid
+		}
+		signedAt := time.Unix(sec, 0)
+		if d := time.Since(signedAt); d > opts.MaxSkew || d < -opts.MaxSkew {
+			return nil, ErrSignatureExpired
+		}
+
+		// 2. 密钥
+		k, err := opts.Keys.Key(r.Context(), keyID, "HS256")
+		if err != nil {
+			if errors.Is(err, ErrTokenKeyNotFound) {
+				return nil, ErrSignatureInvalid
+			}
+			return nil, err
+		}
+		key, ok := k.([]byte)
+		if !ok {
+			return nil, ErrSignatureInvalid
+		}
+
+		// 3. 签名
+		want, err := hex.DecodeString(sig)
+		if err != nil {
+			return nil, ErrSignatureInvalid
+		}
+		body, err := readBody(r, opts.MaxBodySize)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(body)
+		mac := hmac.New(sha256.New, key)
+		writeCanonical(mac, r, ts, nonce, opts.Headers, sum[:])
+		if !hmac.Equal(mac.Sum(nil), want) {
+			return nil, ErrSignatureInvalid
+		}
+
+		// 4. 防重放：必须在签名验证通过之后记录，否则攻击者可以提前占用 nonce
+		if opts.Nonces != nil {
+			fresh, err := opts.Nonces.Use(r.Context(), keyID+":"+nonce, signedAt.Add(opts.MaxSkew))
+			if err != nil {
+				return nil, err
+			}
+			if !fresh {
+				return nil, ErrNonceReplayed
+			}
+		}
+
+		return &HMACIdentity{KeyID: keyID, Timestamp: signedAt, Nonce: nonce}, nil
+	}
+}
+
+// VerifyHMAC 返回一个强制要求 HMAC 签名的中间件，适用于 Webhook 等只接受签名请求的端点。
+// 与 Auth(HMACAuth(opts)) 不同，任何失败 (包括缺少签名与 NonceCache 故障) 都会直接交给 ErrorFunc 处理，
+// 验证通过后 *HMACIdentity 作为身份注入。
+func VerifyHMAC(opts HMACOptions, Errors ...ErrorFunc) Middleware {
+	strategy := HMACAuth(opts)
+	errorFunc := Error
+	if len(Errors) > 0 {
+		errorFunc = Errors[0]
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, err := strategy(w, r)
+			if errors.Is(err, ErrNoCredentials) {
+				err = ErrSignatureMissing
+			}
+			if err != nil {
+				errorFunc(w, r, err)
+				return
+			}
+			ctx := context.WithValue(r.Context(), IdentityKey{}, identity)
+			recordIdentity(ctx, identity)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// SignHMAC 为发出的请求签名，设置 key ID、时间戳、nonce 与签名请求头。
+// 请求体会被读取并恢复 (同时设置 GetBody)。opts 中的 Headers 与请求头名称须与服务端一致。
+func SignHMAC(r *http.Request, keyID string, key []byte, opts HMACOptions) error {
+	opts.setDefaults()
+
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return err
+		}
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	}
+	sum := sha256.Sum256(body)
+
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	nonceStr := hex.EncodeToString(nonce[:])
+
+	mac := hmac.New(sha256.New, key)
+	writeCanonical(mac, r, ts, nonceStr, opts.Headers, sum[:])
+
+	r.Header.Set(opts.KeyIDHeader, keyID)
+	r.Header.Set(opts.TimestampHeader, ts)
+	r.Header.Set(opts.NonceHeader, nonceStr)
+	r.Header.Set(opts.SignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
+
+// writeCanonical 将请求的规范化形式写入 h
+func writeCanonical(h hash.Hash, r *http.Request, ts, nonce string, headers []string, bodyHash []byte) {
+	var sb strings.Builder
+	sb.WriteString(r.Method)
+	sb.WriteByte('\n')
+	u := signedURL(r)
+	sb.WriteString(u.EscapedPath())
+	if u.RawQuery != "" {
+		sb.WriteByte('?')
+		sb.WriteString(u.RawQuery)
+	}
+	sb.WriteByte('\n')
+	sb.WriteString(ts)
+	sb.WriteByte('\n')
+	sb.WriteString(nonce)
+	sb.WriteByte('\n')
+	for _, name := range headers {
+		sb.WriteString(strings.ToLower(name))
+		sb.WriteByte(':')
+		sb.WriteString(strings.TrimSpace(r.Header.Get(name)))
+		sb.WriteByte('\n')
+	}
+	sb.WriteString(hex.EncodeToString(bodyHash))
+	h.Write([]byte(sb.String()))
+}
+
+// signedURL 返回客户端签名时使用的 URL。
+// 服务端的 r.RequestURI 是客户端实际发送的请求目标：挂载在 Router.Mount 或 http.StripPrefix 之后时，
+// r.URL.Path 已被去掉前缀，不能用于验签。客户端构造的请求 RequestURI 为空，直接使用 r.URL。
+func signedURL(r *http.Request) *url.URL {
+	if r.RequestURI != "" {
+		if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+			return u
+		}
+	}
+	return r.URL
+}
+
+// readBody 在 limit 内读取完整的请求体，然后恢复 r.Body 供后续的 Binder 读取。
+// 读取失败时返回 *HttpError (400，超出上限为 413)，使 Auth 直接终止请求，而不是带着读了一半的 Body 继续。
+func readBody(r *http.Request, limit int64) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, ErrRequestEntityTooLarge
+		}
+		return nil, NewError(http.StatusBadRequest, CodeBadRequest, "failed to read request body")
+	}
+	if int64(len(body)) > limit {
+		return nil, ErrRequestEntityTooLarge
+	}
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
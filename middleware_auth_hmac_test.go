@@ -0,0 +1,191 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingNonceCache struct{}
+
+func (failingNonceCache) Use(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	return false, errors.New("cache unavailable")
+}
+
+func TestHMACAuth(t *testing.T) {
+	keys := StaticKeys{"old": []byte("old-secret"), "new": []byte("new-secret")}
+	opts := HMACOptions{Keys: keys, Headers: []string{"Content-Type"}, Nonces: NewMemoryNonceCache()}
+	strategy := HMACAuth(opts)
+
+	signed := func(t *testing.T, keyID string, key []byte) *http.Request {
+		req := httptest.NewRequest("POST", "/hooks/order?source=shop", strings.NewReader(`{"id":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		require.NoError(t, SignHMAC(req, keyID, key, opts))
+		return req
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		req := signed(t, "new", []byte("new-secret"))
+		identity, err := strategy(nil, req)
+		require.NoError(t, err)
+		assert.Equal(t, "new", identity.(*HMACIdentity).KeyID)
+		assert.Equal(t, "new", identity.(*HMACIdentity).IdentitySubject())
+
+		// 请求体被恢复，Binder 可以再次读取
+		body, _ := io.ReadAll(req.Body)
+		assert.Equal(t, `{"id":1}`, string(body))
+	})
+
+	t.Run("RotatedKey", func(t *testing.T) {
+		_, err := strategy(nil, signed(t, "old", []byte("old-secret")))
+		assert.NoError(t, err)
+	})
+
+	tests := []struct {
+		name    string
+		mutate  func(r *http.Request)
+		wantErr error
+	}{
+		{name: "Missing", mutate: func(r *http.Request) { r.Header.Del("X-Signature") }, wantErr: ErrNoCredentials},
+		{name: "UnknownKey", mutate: func(r *http.Request) { r.Header.Set("X-Key-Id", "gone") }, wantErr: ErrSignatureInvalid},
+		{name: "TamperedBody", mutate: func(r *http.Request) { r.Body = io.NopCloser(strings.NewReader(`{"id":2}`)) }, wantErr: ErrSignatureInvalid},
+		{name: "TamperedPath", mutate: func(r *http.Request) {
+			r.URL.Path, r.RequestURI = "/hooks/refund", "/hooks/refund?source=shop"
+		}, wantErr: ErrSignatureInvalid},
+		{name: "TamperedHeader", mutate: func(r *http.Request) { r.Header.Set("Content-Type", "text/plain") }, wantErr: ErrSignatureInvalid},
+		{name: "NotHex", mutate: func(r *http.Request) { r.Header.Set("X-Signature", "zz") }, wantErr: ErrSignatureInvalid},
+		{name: "MissingNonce", mutate: func(r *http.Request) { r.Header.Del("X-Nonce") }, wantErr: ErrSignatureInvalid},
+		{name: "Stale", mutate: func(r *http.Request) {
+			r.Header.Set("X-Timestamp", strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+		}, wantErr: ErrSignatureExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := signed(t, "new", []byte("new-secret"))
+			tt.mutate(req)
+			_, err := strategy(nil, req)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("Replay", func(t *testing.T) {
+		req := signed(t, "new", []byte("new-secret"))
+		replay := req.Clone(context.Background())
+		replay.Body, _ = req.GetBody()
+
+		_, err := strategy(nil, req)
+		require.NoError(t, err)
+		_, err = strategy(nil, replay)
+		assert.ErrorIs(t, err, ErrNonceReplayed)
+	})
+
+	t.Run("BodyTooLarge", func(t *testing.T) {
+		small := opts
+		small.MaxBodySize = 4
+		small.Nonces = nil
+		_, err := HMACAuth(small)(nil, signed(t, "new", []byte("new-secret")))
+		assert.ErrorIs(t, err, ErrRequestEntityTooLarge)
+	})
+
+	t.Run("BodyReadError", func(t *testing.T) {
+		noNonce := opts
+		noNonce.Nonces = nil
+		called := false
+		h := Auth(HMACAuth(noNonce))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+		// 读取中途失败的 Body 终止请求 (400)，而不是作为认证错误继续交给下游
+		req := signed(t, "new", []byte("new-secret"))
+		req.Body = io.NopCloser(io.MultiReader(strings.NewReader(`{"id"`), iotest.ErrReader(io.ErrUnexpectedEOF)))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.False(t, called)
+
+		// 服务端 MaxBytesReader 的限制同样返回 413
+		req = signed(t, "new", []byte("new-secret"))
+		w = httptest.NewRecorder()
+		req.Body = http.MaxBytesReader(w, req.Body, 2)
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.False(t, called)
+	})
+}
+
+func TestHMACAuth_Mounted(t *testing.T) {
+	opts := HMACOptions{Keys: StaticKeys{"k": []byte("secret")}}
+	hooks := NewRouter()
+	hooks.Handle("POST /order", Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), Auth(HMACAuth(opts)), AuthRequired(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})))
+	router := NewRouter()
+	router.Mount("/tenants/{tenant}/hooks", hooks)
+	router.Mount("/hooks", hooks)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	// 客户端对完整路径签名，挂载的子路由看到的是去掉前缀后的路径
+	for _, path := range []string{"/hooks/order?source=shop", "/tenants/acme/hooks/order"} {
+		req, err := http.NewRequest("POST", srv.URL+path, strings.NewReader(`{"id":1}`))
+		require.NoError(t, err)
+		require.NoError(t, SignHMAC(req, "k", []byte("secret"), opts))
+		resp, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode, path)
+	}
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	opts := HMACOptions{Keys: StaticKeys{"k1": []byte("secret")}}
+	handler := VerifyHMAC(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(GetIdentity(r.Context()).(*HMACIdentity).KeyID + ":" + string(body)))
+	}))
+
+	req := httptest.NewRequest("POST", "/hook", strings.NewReader("payload"))
+	require.NoError(t, SignHMAC(req, "k1", []byte("secret"), opts))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "k1:payload", w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/hook", strings.NewReader("payload")))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// NonceCache 故障不能放行
+	opts.Nonces = failingNonceCache{}
+	req = httptest.NewRequest("POST", "/hook", nil)
+	require.NoError(t, SignHMAC(req, "k1", []byte("secret"), opts))
+	w = httptest.NewRecorder()
+	VerifyHMAC(opts)(http.NotFoundHandler()).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestMemoryNonceCache(t *testing.T) {
+	c := NewMemoryNonceCache()
+	ctx := context.Background()
+
+	fresh, _ := c.Use(ctx, "n1", time.Now().Add(time.Minute))
+	assert.True(t, fresh)
+	fresh, _ = c.Use(ctx, "n1", time.Now().Add(time.Minute))
+	assert.False(t, fresh)
+
+	// 过期的 nonce 可以被重新使用 (时间戳校验会先拒绝它)
+	fresh, _ = c.Use(ctx, "n2", time.Now().Add(-time.Second))
+	assert.True(t, fresh)
+	fresh, _ = c.Use(ctx, "n2", time.Now().Add(time.Minute))
+	assert.True(t, fresh)
+}
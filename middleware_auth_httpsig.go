@@ -0,0 +1,650 @@
+package httpx
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// HTTP Message Signatures (RFC 9421) 验证失败的错误
+var (
+	ErrHTTPSignatureInvalid = &HttpError{HttpCode: http.StatusUnauthorized, BizCode: CodeUnauthorized, Msg: "invalid HTTP message signature"}
+	ErrHTTPSignatureExpired = &HttpError{HttpCode: http.StatusUnauthorized, BizCode: CodeUnauthorized, Msg: "HTTP message signature expired"}
+	ErrContentDigest        = &HttpError{HttpCode: http.StatusBadRequest, BizCode: CodeBadRequest, Msg: "Content-Digest does not match the request body"}
+)
+
+// httpSigAlgs 将 RFC 9421 的算法名映射到对应的 JWS 算法，复用 JWT 的验签实现
+var httpSigAlgs = map[string]string{
+	"ed25519":           "EdDSA",
+	"ecdsa-p256-sha256": "ES256",
+	"ecdsa-p384-sha384": "ES384",
+	"rsa-pss-sha512":    "PS512",
+	"rsa-v1_5-sha256":   "RS256",
+	"hmac-sha256":       "HS256",
+}
+
+// HTTPSignatureOptions 配置 RFC 9421 签名验证。
+type HTTPSignatureOptions struct {
+	// Keys 按签名参数中的 keyid 与 alg 解析验签密钥，alg 以对应的 JWS 名称 (如 EdDSA、PS512) 传入，
+	// 签名未声明 alg 时为空。必填。
+	// 密钥类型：ed25519 为 ed25519.PublicKey，ecdsa-* 为 *ecdsa.PublicKey，
+	// rsa-* 为 *rsa.PublicKey，hmac-sha256 为 []byte。StaticKeys 与 JWKS 均可直接使用。
+	Keys KeySet
+
+	// Algorithms 允许的算法。签名未声明 alg 时按密钥类型推断 (RSA 密钥推断为 rsa-pss-sha512)。
+	// 默认允许全部支持的算法。
+	Algorithms []string
+
+	// Label 只验证该标签的签名。为空时依次尝试请求中的每个签名，任意一个通过即可。
+	Label string
+
+	// Tag 非空时要求签名参数中的 tag 与之相等，用于区分不同应用场景的签名。
+	Tag string
+
+	// RequiredComponents 签名必须覆盖的组件。默认 @method、@authority 与 @path；
+	// 覆盖 @target-uri 视为同时覆盖了 @scheme、@authority、@path 与 @query。
+	// 请求带有 body 时，还要求覆盖 content-digest。
+	RequiredComponents []string
+
+	// MaxAge 签名 created 参数允许的最大年龄，同时作为未来时间的容忍偏差。默认 5 分钟。
+	MaxAge time.Duration
+
+	// Nonces 非 nil 时要求签名携带 nonce 参数，并拒绝重复的 nonce。
+	Nonces NonceCache
+
+	// MaxBodySize 校验 Content-Digest 时缓冲请求体的上限。默认 2MB。
+	MaxBodySize int64
+}
+
+// HTTPSignatureIdentity 是签名验证通过后注入的身份。
+type HTTPSignatureIdentity struct {
+	Label      string
+	KeyID      string
+	Alg        string
+	Created    time.Time
+	Components []string
+}
+
+// IdentitySubject 实现 SubjectHolder，日志中只记录 KeyID。
+func (id HTTPSignatureIdentity) IdentitySubject() string {
+	return id.KeyID
+}
+
+// HTTPSignatureAuth 创建一个验证 RFC 9421 Signature-Input / Signature 请求头的认证策略。
+// 请求未携带签名时返回 ErrNoCredentials，可与其他策略通过 AuthChain 组合。
+// 请求带有 Content-Digest 时会按 RFC 9530 校验请求体 (支持 sha-256 与 sha-512)，之后恢复 r.Body。
+//
+// @scheme 与 @target-uri 中的 scheme 取自 r.URL.Scheme，为空时根据 r.TLS 判断。
+// 在 TLS 终止于代理的部署中，可在前置中间件中设置 r.URL.Scheme，或让对方签名 @authority/@path 而非 @target-uri。
+func HTTPSignatureAuth(opts HTTPSignatureOptions) AuthStrategy {
+	if opts.Keys == nil {
+		panic("httpx: HTTPSignatureOptions.Keys is required")
+	}
+	if len(opts.Algorithms) == 0 {
+		for alg := range httpSigAlgs {
+			opts.Algorithms = append(opts.Algorithms, alg)
+		}
+	}
+	for _, alg := range opts.Algorithms {
+		if _, ok := httpSigAlgs[alg]; !ok {
+			panic(fmt.Sprintf("httpx: unsupported HTTP signature algorithm %q", alg))
+		}
+	}
+	if len(opts.RequiredComponents) == 0 {
+		opts.RequiredComponents = []string{"@method", "@authority", "@path"}
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = 5 * time.Minute
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = 2 << 20
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) (any, error) {
+		rawInput := strings.Join(r.Header.Values("Signature-Input"), ", ")
+		if rawInput == "" {
+			return nil, ErrNoCredentials
+		}
+		inputs, err := parseSFDictionary(rawInput)
+		if err != nil {
+			return nil, ErrHTTPSignatureInvalid
+		}
+		sigs, err := parseSFDictionary(strings.Join(r.Header.Values("Signature"), ", "))
+		if err != nil {
+			return nil, ErrHTTPSignatureInvalid
+		}
+
+		// 1. Content-Digest 与签名无关，先行校验
+		if digest := r.Header.Get("Content-Digest"); digest != "" {
+			body, err := readBody(r, opts.MaxBodySize)
+			if err != nil {
+				return nil, err
+			}
+			if err := verifyContentDigest(digest, body); err != nil {
+				return nil, err
+			}
+		}
+
+		// 2. 依次尝试每个签名
+		err = ErrHTTPSignatureInvalid
+		for _, input := range inputs {
+			if input.inner == nil || (opts.Label != "" && input.name != opts.Label) {
+				continue
+			}
+			var identity *HTTPSignatureIdentity
+			if identity, err = verifyHTTPSignature(r, &opts, input, sigs); err == nil {
+				return identity, nil
+			}
+		}
+		return nil, err
+	}
+}
+
+func verifyHTTPSignature(r *http.Request, opts *HTTPSignatureOptions, input sfMember, sigs []sfMember) (*HTTPSignatureIdentity, error) {
+	i := slices.IndexFunc(sigs, func(m sfMember) bool { return m.name == input.name })
+	if i < 0 {
+		return nil, ErrHTTPSignatureInvalid
+	}
+	sig, ok := sigs[i].item.value.([]byte)
+	if !ok {
+		return nil, ErrHTTPSignatureInvalid
+	}
+
+	// 1. 签名参数
+	params := input.params
+	keyID, alg, nonce := params.str("keyid"), params.str("alg"), params.str("nonce")
+	if opts.Tag != "" && params.str("tag") != opts.Tag {
+		return nil, ErrHTTPSignatureInvalid
+	}
+	created, ok := params.get("created")
+	if !ok {
+		return nil, ErrHTTPSignatureInvalid
+	}
+	createdUnix, ok := created.(int64)
+	if !ok {
+		return nil, ErrHTTPSignatureInvalid
+	}
+	now := time.Now()
+	createdAt := time.Unix(createdUnix, 0)
+	if createdAt.After(now.Add(opts.MaxAge)) || now.Sub(createdAt) > opts.MaxAge {
+		return nil, ErrHTTPSignatureExpired
+	}
+	if v, ok := params.get("expires"); ok {
+		exp, ok := v.(int64)
+		if !ok {
+			return nil, ErrHTTPSignatureInvalid
+		}
+		if !now.Before(time.Unix(exp, 0)) {
+			return nil, ErrHTTPSignatureExpired
+		}
+	}
+	if opts.Nonces != nil && nonce == "" {
+		return nil, ErrHTTPSignatureInvalid
+	}
+
+	// 2. 覆盖的组件。RFC 9421 §2 不允许重复的组件标识 (名称与参数均相同)
+	covered := make([]string, 0, len(input.inner))
+	seen := make(map[string]struct{}, len(input.inner))
+	for _, item := range input.inner {
+		name, ok := item.value.(string)
+		if !ok {
+			return nil, ErrHTTPSignatureInvalid
+		}
+		var id strings.Builder
+		writeSFItem(&id, item)
+		if _, dup := seen[id.String()]; dup {
+			return nil, ErrHTTPSignatureInvalid
+		}
+		seen[id.String()] = struct{}{}
+		covered = append(covered, name)
+	}
+	if !coversRequired(covered, opts.RequiredComponents) {
+		return nil, ErrHTTPSignatureInvalid
+	}
+	if r.ContentLength != 0 && r.Body != nil && r.Body != http.NoBody && !slices.Contains(covered, "content-digest") {
+		return nil, ErrHTTPSignatureInvalid
+	}
+	base, err := signatureBase(r, input.inner, params)
+	if err != nil {
+		return nil, ErrHTTPSignatureInvalid
+	}
+
+	// 3. 密钥与算法
+	if alg != "" && !slices.Contains(opts.Algorithms, alg) {
+		return nil, ErrHTTPSignatureInvalid
+	}
+	key, err := opts.Keys.Key(r.Context(), keyID, httpSigAlgs[alg])
+	if err != nil {
+		if errors.Is(err, ErrTokenKeyNotFound) {
+			return nil, ErrHTTPSignatureInvalid
+		}
+		return nil, err
+	}
+	if alg == "" {
+		alg = inferHTTPSigAlg(key)
+	}
+	if !slices.Contains(opts.Algorithms, alg) {
+		return nil, ErrHTTPSignatureInvalid
+	}
+	if verifySignature(httpSigAlgs[alg], key, base, sig) != nil {
+		return nil, ErrHTTPSignatureInvalid
+	}
+
+	// 4. 防重放：签名通过后才记录 nonce
+	if opts.Nonces != nil {
+		fresh, err := opts.Nonces.Use(r.Context(), keyID+":"+nonce, createdAt.Add(opts.MaxAge))
+		if err != nil {
+			return nil, err
+		}
+		if !fresh {
+			return nil, ErrNonceReplayed
+		}
+	}
+
+	return &HTTPSignatureIdentity{Label: input.name, KeyID: keyID, Alg: alg, Created: createdAt, Components: covered}, nil
+}
+
+// coversRequired 检查 covered 是否包含 required 中的全部组件
+func coversRequired(covered, required []string) bool {
+	for _, c := range required {
+		if slices.Contains(covered, c) {
+			continue
+		}
+		switch c {
+		case "@scheme", "@authority", "@path", "@query":
+			if slices.Contains(covered, "@target-uri") {
+				continue
+			}
+		}
+		if (c == "@path" || c == "@query") && slices.Contains(covered, "@request-target") {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// signatureBase 按 RFC 9421 §2.5 构造签名基串
+func signatureBase(r *http.Request, components []sfItem, params sfParams) (string, error) {
+	var sb strings.Builder
+	for _, c := range components {
+		name, _ := c.value.(string)
+		values, err := componentValues(r, name, c.params)
+		if err != nil {
+			return "", err
+		}
+		for _, v := range values {
+			writeSFItem(&sb, c)
+			sb.WriteString(": ")
+			sb.WriteString(v)
+			sb.WriteByte('\n')
+		}
+	}
+	sb.WriteString(`"@signature-params": `)
+	writeSFInnerList(&sb, components, params)
+	return sb.String(), nil
+}
+
+// componentValues 返回一个组件的值。@query-param 对同名参数的每个值各占一行，其余组件只有一个值。
+// 路径与查询取自客户端发送的请求目标 (signedURL)，挂载在 Router.Mount 之后同样可以验签。
+func componentValues(r *http.Request, name string, params sfParams) ([]string, error) {
+	u := signedURL(r)
+	if name == "@query-param" {
+		if len(params) != 1 || params[0].key != "name" {
+			return nil, errStructuredField
+		}
+		encoded, _ := params[0].value.(string)
+		key, err := url.QueryUnescape(encoded)
+		if err != nil {
+			return nil, err
+		}
+		values, ok := u.Query()[key]
+		if !ok {
+			return nil, fmt.Errorf("missing query parameter %q", key)
+		}
+		out := make([]string, len(values))
+		for i, v := range values {
+			out[i] = strings.ReplaceAll(url.QueryEscape(v), "+", "%20")
+		}
+		return out, nil
+	}
+	// 其余组件参数 (sf、key、bs、req、tr) 暂不支持
+	if len(params) > 0 {
+		return nil, errStructuredField
+	}
+
+	scheme := r.URL.Scheme
+	if scheme == "" {
+		scheme = "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	var v string
+	switch name {
+	case "@method":
+		v = r.Method
+	case "@scheme":
+		v = scheme
+	case "@authority":
+		v = httpSigAuthority(r, scheme)
+	case "@path":
+		v = path
+	case "@query":
+		v = "?" + u.RawQuery
+	case "@request-target":
+		v = u.RequestURI()
+	case "@target-uri":
+		v = scheme + "://" + httpSigAuthority(r, scheme) + u.RequestURI()
+	default:
+		if strings.HasPrefix(name, "@") || name != strings.ToLower(name) {
+			return nil, fmt.Errorf("unsupported component %q", name)
+		}
+		values := r.Header.Values(name)
+		if len(values) == 0 {
+			return nil, fmt.Errorf("missing header %q", name)
+		}
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+		v = strings.Join(values, ", ")
+	}
+	return []string{v}, nil
+}
+
+// httpSigAuthority 返回小写且去掉默认端口的 authority
+func httpSigAuthority(r *http.Request, scheme string) string {
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	host = strings.ToLower(host)
+	if (scheme == "http" && strings.HasSuffix(host, ":80")) || (scheme == "https" && strings.HasSuffix(host, ":443")) {
+		host = host[:strings.LastIndexByte(host, ':')]
+	}
+	return host
+}
+
+// inferHTTPSigAlg 根据密钥类型推断算法
+func inferHTTPSigAlg(key any) string {
+	switch k := key.(type) {
+	case []byte:
+		return "hmac-sha256"
+	case ed25519.PublicKey, ed25519.PrivateKey:
+		return "ed25519"
+	case *ecdsa.PublicKey:
+		return ecdsaHTTPSigAlg(k)
+	case *ecdsa.PrivateKey:
+		return ecdsaHTTPSigAlg(&k.PublicKey)
+	case *rsa.PublicKey, *rsa.PrivateKey:
+		return "rsa-pss-sha512"
+	}
+	return ""
+}
+
+func ecdsaHTTPSigAlg(k *ecdsa.PublicKey) string {
+	switch k.Curve.Params().BitSize {
+	case 256:
+		return "ecdsa-p256-sha256"
+	case 384:
+		return "ecdsa-p384-sha384"
+	}
+	return ""
+}
+
+// verifyContentDigest 按 RFC 9530 校验 Content-Digest。
+// 所有受支持的摘要都必须匹配，且至少要有一个受支持的摘要。
+func verifyContentDigest(header string, body []byte) error {
+	members, err := parseSFDictionary(header)
+	if err != nil {
+		return ErrContentDigest
+	}
+	checked := false
+	for _, m := range members {
+		want, ok := m.item.value.([]byte)
+		if !ok {
+			return ErrContentDigest
+		}
+		var got []byte
+		switch m.name {
+		case "sha-256":
+			sum := sha256.Sum256(body)
+			got = sum[:]
+		case "sha-512":
+			sum := sha512.Sum512(body)
+			got = sum[:]
+		default:
+			continue
+		}
+		if !hmac.Equal(got, want) {
+			return ErrContentDigest
+		}
+		checked = true
+	}
+	if !checked {
+		return ErrContentDigest
+	}
+	return nil
+}
+
+// HTTPSigner 按 RFC 9421 为发出的请求签名。
+type HTTPSigner struct {
+	// KeyID 写入签名参数 keyid。
+	KeyID string
+
+	// Key 签名私钥：ed25519.PrivateKey、*ecdsa.PrivateKey、*rsa.PrivateKey 或 HMAC 密钥 []byte。
+	Key any
+
+	// Alg 签名算法。为空时按密钥类型推断，且不写入签名参数 (由验证方根据 keyid 确定)。
+	Alg string
+
+	// Label 签名标签。默认 "sig1"。
+	Label string
+
+	// Components 覆盖的组件，如 "@method"、"content-type"、`@query-param;name="id"`。
+	// 默认 @method、@authority、@path 与 @query。请求带有 body 时会自动设置并覆盖 Content-Digest。
+	Components []string
+
+	// Tag 非空时写入签名参数 tag。
+	Tag string
+
+	// Expires 非零时写入签名参数 expires = created + Expires。
+	Expires time.Duration
+
+	// Nonce 为 true 时写入随机 nonce，配合验证方的 NonceCache 防重放。
+	Nonce bool
+}
+
+// Sign 为请求设置 Signature-Input 与 Signature 请求头 (以及必要时的 Content-Digest)。
+// 请求体会被读取并恢复 (同时设置 GetBody)。
+func (s *HTTPSigner) Sign(r *http.Request) error {
+	alg := s.Alg
+	if alg == "" {
+		alg = inferHTTPSigAlg(s.Key)
+	}
+	jwsAlg, ok := httpSigAlgs[alg]
+	if !ok {
+		return fmt.Errorf("httpx: unsupported HTTP signature algorithm %q", alg)
+	}
+	label := s.Label
+	if label == "" {
+		label = "sig1"
+	}
+	names := s.Components
+	if len(names) == 0 {
+		names = []string{"@method", "@authority", "@path", "@query"}
+	}
+
+	// 1. 请求体摘要
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+		if r.Header.Get("Content-Digest") == "" {
+			sum := sha256.Sum256(body)
+			var sb strings.Builder
+			sb.WriteString("sha-256=")
+			writeSFBareItem(&sb, sum[:])
+			r.Header.Set("Content-Digest", sb.String())
+		}
+		if !slices.Contains(names, "content-digest") {
+			names = append(slices.Clip(names), "content-digest")
+		}
+	}
+
+	// 2. 组件与签名参数
+	components := make([]sfItem, len(names))
+	for i, name := range names {
+		id, params, _ := strings.Cut(name, ";")
+		item, err := parseSFItem(`"` + id + `"` + ";" + params)
+		if params == "" {
+			item, err = sfItem{value: id}, nil
+		}
+		if err != nil {
+			return fmt.Errorf("httpx: bad signature component %q", name)
+		}
+		if slices.Contains(names[:i], name) {
+			return fmt.Errorf("httpx: duplicate signature component %q", name)
+		}
+		components[i] = item
+	}
+
+	now := time.Now()
+	params := sfParams{{key: "created", value: now.Unix()}}
+	if s.Expires > 0 {
+		params = append(params, sfParam{key: "expires", value: now.Add(s.Expires).Unix()})
+	}
+	if s.Nonce {
+		var buf [16]byte
+		if _, err := rand.Read(buf[:]); err != nil {
+			return err
+		}
+		params = append(params, sfParam{key: "nonce", value: hex.EncodeToString(buf[:])})
+	}
+	if s.Alg != "" {
+		params = append(params, sfParam{key: "alg", value: s.Alg})
+	}
+	if s.KeyID != "" {
+		params = append(params, sfParam{key: "keyid", value: s.KeyID})
+	}
+	if s.Tag != "" {
+		params = append(params, sfParam{key: "tag", value: s.Tag})
+	}
+
+	// 3. 签名
+	base, err := signatureBase(r, components, params)
+	if err != nil {
+		return fmt.Errorf("httpx: build signature base: %w", err)
+	}
+	sig, err := signMessage(jwsAlg, s.Key, []byte(base))
+	if err != nil {
+		return err
+	}
+
+	var input, signature strings.Builder
+	input.WriteString(label)
+	input.WriteByte('=')
+	writeSFInnerList(&input, components, params)
+	signature.WriteString(label)
+	signature.WriteByte('=')
+	writeSFBareItem(&signature, sig)
+	r.Header.Set("Signature-Input", input.String())
+	r.Header.Set("Signature", signature.String())
+	return nil
+}
+
+// Transport 返回一个在发送前为每个请求签名的 RoundTripper。base 为 nil 时使用 http.DefaultTransport。
+func (s *HTTPSigner) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		// RoundTripper 不应修改传入的请求
+		r = r.Clone(r.Context())
+		if err := s.Sign(r); err != nil {
+			return nil, err
+		}
+		return base.RoundTrip(r)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// signMessage 使用 JWS 算法名对 data 签名，是 verifySignature 的逆操作
+func signMessage(alg string, key any, data []byte) ([]byte, error) {
+	errKey := fmt.Errorf("httpx: key type %T does not match algorithm %s", key, alg)
+	if alg == "EdDSA" {
+		priv, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errKey
+		}
+		return ed25519.Sign(priv, data), nil
+	}
+	hash, _ := jwtHash(alg)
+	if alg[:2] == "HS" {
+		secret, ok := key.([]byte)
+		if !ok {
+			return nil, errKey
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write(data)
+		return mac.Sum(nil), nil
+	}
+
+	h := hash.New()
+	h.Write(data)
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		priv, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errKey
+		}
+		if alg[:2] == "RS" {
+			return rsa.SignPKCS1v15(rand.Reader, priv, hash, digest)
+		}
+		return rsa.SignPSS(rand.Reader, priv, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES":
+		priv, ok := key.(*ecdsa.PrivateKey)
+		if !ok || priv.Curve.Params().BitSize != ecdsaCurveBits(hash) {
+			return nil, errKey
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, priv, digest)
+		if err != nil {
+			return nil, err
+		}
+		// JWS 与 RFC 9421 均使用定长的 r || s 编码
+		size := (priv.Curve.Params().BitSize + 7) / 8
+		sig := make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+		return sig, nil
+	}
+	return nil, fmt.Errorf("httpx: unsupported algorithm %s", alg)
+}
@@ -0,0 +1,276 @@
+package httpx
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 9421 附录 B.2.6 中的签名基串
+func TestSignatureBase_RFC9421(t *testing.T) {
+	req := httptest.NewRequest("POST", "/foo?param=Value&Pet=dog", strings.NewReader(`{"hello": "world"}`))
+	req.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Length", "18")
+
+	inputs, err := parseSFDictionary(`sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`)
+	require.NoError(t, err)
+	base, err := signatureBase(req, inputs[0].inner, inputs[0].params)
+	require.NoError(t, err)
+	assert.Equal(t, `"date": Tue, 20 Apr 2021 02:07:55 GMT
+"@method": POST
+"@path": /foo
+"@authority": example.com
+"content-type": application/json
+"content-length": 18
+"@signature-params": ("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`, base)
+
+	req.URL.Scheme = "https"
+	req.Host = "Example.com:443"
+	inputs, err = parseSFDictionary(`sig=("@target-uri" "@request-target" "@query" "@query-param";name="param" "@scheme")`)
+	require.NoError(t, err)
+	base, err = signatureBase(req, inputs[0].inner, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `"@target-uri": https://example.com/foo?param=Value&Pet=dog
+"@request-target": /foo?param=Value&Pet=dog
+"@query": ?param=Value&Pet=dog
+"@query-param";name="param": Value
+"@scheme": https
+"@signature-params": ("@target-uri" "@request-target" "@query" "@query-param";name="param" "@scheme")`, base)
+}
+
+func TestHTTPSignatureAuth_Algorithms(t *testing.T) {
+	edPub, edPriv, _ := ed25519.GenerateKey(rand.Reader)
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	tests := []struct {
+		alg  string
+		priv any
+		pub  any
+	}{
+		{"ed25519", edPriv, edPub},
+		{"ecdsa-p256-sha256", p256, &p256.PublicKey},
+		{"ecdsa-p384-sha384", p384, &p384.PublicKey},
+		{"rsa-pss-sha512", rsaKey, &rsaKey.PublicKey},
+		{"rsa-v1_5-sha256", rsaKey, &rsaKey.PublicKey},
+		{"hmac-sha256", []byte("shared-secret"), []byte("shared-secret")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			strategy := HTTPSignatureAuth(HTTPSignatureOptions{Keys: StaticKeys{"k1": tt.pub}})
+			signer := &HTTPSigner{KeyID: "k1", Key: tt.priv, Alg: tt.alg}
+
+			req := httptest.NewRequest("POST", "/orders?id=1", strings.NewReader(`{"sku":"a"}`))
+			require.NoError(t, signer.Sign(req))
+			identity, err := strategy(nil, req)
+			require.NoError(t, err)
+			id := identity.(*HTTPSignatureIdentity)
+			assert.Equal(t, "k1", id.KeyID)
+			assert.Equal(t, "k1", id.IdentitySubject())
+			assert.Equal(t, tt.alg, id.Alg)
+			assert.Contains(t, id.Components, "content-digest")
+
+			body, _ := io.ReadAll(req.Body)
+			assert.Equal(t, `{"sku":"a"}`, string(body))
+		})
+	}
+
+	t.Run("InferredAlg", func(t *testing.T) {
+		strategy := HTTPSignatureAuth(HTTPSignatureOptions{Keys: StaticKeys{"k1": edPub}})
+		req := httptest.NewRequest("GET", "/", nil)
+		require.NoError(t, (&HTTPSigner{KeyID: "k1", Key: edPriv}).Sign(req))
+		assert.NotContains(t, req.Header.Get("Signature-Input"), "alg=")
+		identity, err := strategy(nil, req)
+		require.NoError(t, err)
+		assert.Equal(t, "ed25519", identity.(*HTTPSignatureIdentity).Alg)
+	})
+
+	t.Run("KeyTypeMismatch", func(t *testing.T) {
+		// 声明 hmac-sha256 但密钥是公钥，不能被当作 HMAC 密钥使用
+		strategy := HTTPSignatureAuth(HTTPSignatureOptions{Keys: StaticKeys{"k1": edPub}})
+		req := httptest.NewRequest("GET", "/", nil)
+		require.NoError(t, (&HTTPSigner{KeyID: "k1", Key: []byte(edPub), Alg: "hmac-sha256"}).Sign(req))
+		_, err := strategy(nil, req)
+		assert.ErrorIs(t, err, ErrHTTPSignatureInvalid)
+	})
+}
+
+func TestHTTPSignatureAuth_Rejections(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	opts := HTTPSignatureOptions{Keys: StaticKeys{"k1": pub}, Nonces: NewMemoryNonceCache(), Tag: "partner"}
+	strategy := HTTPSignatureAuth(opts)
+	signer := &HTTPSigner{KeyID: "k1", Key: priv, Nonce: true, Tag: "partner"}
+
+	signed := func(t *testing.T, s *HTTPSigner) *http.Request {
+		req := httptest.NewRequest("PUT", "/items/1", strings.NewReader("data"))
+		require.NoError(t, s.Sign(req))
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		signer  *HTTPSigner
+		mutate  func(r *http.Request)
+		wantErr error
+	}{
+		{name: "Missing", mutate: func(r *http.Request) { r.Header.Del("Signature-Input") }, wantErr: ErrNoCredentials},
+		{name: "TamperedPath", mutate: func(r *http.Request) { r.URL.Path, r.RequestURI = "/items/2", "/items/2" }, wantErr: ErrHTTPSignatureInvalid},
+		{name: "TamperedMethod", mutate: func(r *http.Request) { r.Method = "DELETE" }, wantErr: ErrHTTPSignatureInvalid},
+		{name: "TamperedBody", mutate: func(r *http.Request) { r.Body = io.NopCloser(strings.NewReader("evil")) }, wantErr: ErrContentDigest},
+		{name: "UnknownKey", signer: &HTTPSigner{KeyID: "k2", Key: priv, Nonce: true, Tag: "partner"}, wantErr: ErrHTTPSignatureInvalid},
+		{name: "WrongTag", signer: &HTTPSigner{KeyID: "k1", Key: priv, Nonce: true, Tag: "other"}, wantErr: ErrHTTPSignatureInvalid},
+		{name: "NoNonce", signer: &HTTPSigner{KeyID: "k1", Key: priv, Tag: "partner"}, wantErr: ErrHTTPSignatureInvalid},
+		{name: "BodyNotCovered", mutate: func(r *http.Request) {
+			r.Header.Del("Content-Digest")
+		}, wantErr: ErrHTTPSignatureInvalid},
+		{name: "MissingRequired", signer: &HTTPSigner{KeyID: "k1", Key: priv, Nonce: true, Tag: "partner", Components: []string{"@method"}}, wantErr: ErrHTTPSignatureInvalid},
+		{name: "Expired", signer: &HTTPSigner{KeyID: "k1", Key: priv, Nonce: true, Tag: "partner", Expires: time.Nanosecond}, wantErr: ErrHTTPSignatureExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := signer
+			if tt.signer != nil {
+				s = tt.signer
+			}
+			req := signed(t, s)
+			if tt.mutate != nil {
+				tt.mutate(req)
+			}
+			_, err := strategy(nil, req)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("Replay", func(t *testing.T) {
+		req := signed(t, signer)
+		replay := req.Clone(req.Context())
+		replay.Body, _ = req.GetBody()
+		_, err := strategy(nil, req)
+		require.NoError(t, err)
+		_, err = strategy(nil, replay)
+		assert.ErrorIs(t, err, ErrNonceReplayed)
+	})
+
+	t.Run("AlgorithmNotAllowed", func(t *testing.T) {
+		only := HTTPSignatureAuth(HTTPSignatureOptions{Keys: StaticKeys{"k1": []byte("s")}, Algorithms: []string{"ed25519"}})
+		req := httptest.NewRequest("GET", "/", nil)
+		require.NoError(t, (&HTTPSigner{KeyID: "k1", Key: []byte("s")}).Sign(req))
+		_, err := only(nil, req)
+		assert.ErrorIs(t, err, ErrHTTPSignatureInvalid)
+	})
+
+	t.Run("DuplicateComponents", func(t *testing.T) {
+		key := []byte("shared-secret")
+		hmacOnly := HTTPSignatureAuth(HTTPSignatureOptions{Keys: StaticKeys{"k1": key}})
+		for _, list := range []string{
+			`("@method" "@authority" "@path" "@method")`,
+			`("@method" "@authority" "@path" "@query-param";name="a" "@query-param";name="a")`,
+		} {
+			req := httptest.NewRequest("GET", "/?a=1", nil)
+			input := list + `;created=` + strconv.FormatInt(time.Now().Unix(), 10) + `;keyid="k1";alg="hmac-sha256"`
+			inputs, err := parseSFDictionary("sig1=" + input)
+			require.NoError(t, err)
+			base, err := signatureBase(req, inputs[0].inner, inputs[0].params)
+			require.NoError(t, err)
+			mac := hmac.New(sha256.New, key)
+			mac.Write([]byte(base))
+			req.Header.Set("Signature-Input", "sig1="+input)
+			req.Header.Set("Signature", "sig1=:"+base64.StdEncoding.EncodeToString(mac.Sum(nil))+":")
+
+			_, err = hmacOnly(nil, req)
+			assert.ErrorIs(t, err, ErrHTTPSignatureInvalid, list)
+		}
+
+		// 同名但参数不同的组件不算重复
+		req := httptest.NewRequest("GET", "/?a=1&b=2", nil)
+		signer := &HTTPSigner{KeyID: "k1", Key: key, Components: []string{"@method", "@authority", "@path", `@query-param;name="a"`, `@query-param;name="b"`}}
+		require.NoError(t, signer.Sign(req))
+		_, err := hmacOnly(nil, req)
+		assert.NoError(t, err)
+
+		signer.Components = []string{"@method", "@authority", "@path", "@method"}
+		assert.ErrorContains(t, signer.Sign(httptest.NewRequest("GET", "/", nil)), "duplicate signature component")
+	})
+
+	t.Run("MultipleSignatures", func(t *testing.T) {
+		// 代理追加了一个无法验证的签名，不影响原签名
+		req := httptest.NewRequest("GET", "/", nil)
+		require.NoError(t, (&HTTPSigner{KeyID: "proxy", Key: []byte("x"), Label: "proxy"}).Sign(req))
+		input, sig := req.Header.Get("Signature-Input"), req.Header.Get("Signature")
+		require.NoError(t, (&HTTPSigner{KeyID: "k1", Key: priv, Nonce: true, Tag: "partner"}).Sign(req))
+		req.Header.Add("Signature-Input", input)
+		req.Header.Add("Signature", sig)
+
+		_, err := strategy(nil, req)
+		require.NoError(t, err)
+	})
+}
+
+func TestVerifyContentDigest(t *testing.T) {
+	// RFC 9530 §2 示例
+	body := []byte(`{"hello": "world"}`)
+	assert.NoError(t, verifyContentDigest(`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`, body))
+	assert.NoError(t, verifyContentDigest(`md5=:AAAA:, sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`, body))
+	assert.ErrorIs(t, verifyContentDigest(`sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:`, []byte("x")), ErrContentDigest)
+	assert.ErrorIs(t, verifyContentDigest(`md5=:AAAA:`, body), ErrContentDigest)
+}
+
+func TestHTTPSigner_Transport(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	handler := Auth(HTTPSignatureAuth(HTTPSignatureOptions{Keys: StaticKeys{"svc": pub}}))(
+		AuthRequired(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusUnauthorized) })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Write([]byte(GetIdentity(r.Context()).(*HTTPSignatureIdentity).KeyID + ":" + string(body)))
+		})))
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	client := &http.Client{Transport: (&HTTPSigner{KeyID: "svc", Key: priv}).Transport(nil)}
+	req, _ := http.NewRequest("POST", srv.URL+"/internal?x=1", strings.NewReader("hello"))
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "svc:hello", string(body))
+	assert.Empty(t, req.Header.Get("Signature"), "original request must not be modified")
+}
+
+func TestHTTPSignatureAuth_Mounted(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	api := NewRouter()
+	api.Use(Auth(HTTPSignatureAuth(HTTPSignatureOptions{Keys: StaticKeys{"svc": pub}})),
+		AuthRequired(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusUnauthorized) }))
+	api.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	router := NewRouter()
+	router.Mount("/v1", api)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	// 签名覆盖客户端发送的完整路径 (@path = /v1/items/1)，子路由看到的是 /items/1
+	signer := &HTTPSigner{KeyID: "svc", Key: priv, Components: []string{"@method", "@authority", "@path", "@query"}}
+	client := &http.Client{Transport: signer.Transport(nil)}
+	resp, err := client.Get(srv.URL + "/v1/items/1?x=1")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
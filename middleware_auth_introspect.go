@@ -0,0 +1,316 @@
+package httpx
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// ErrTokenInactive 表示授权服务器认为 Token 无效 (active=false)：
+// 可能已过期、被吊销，或根本不是该服务器签发的。
+var ErrTokenInactive = errors.New("token is not active")
+
+// IntrospectionResult 是 RFC 7662 §2.2 的内省响应，验证成功后以 *IntrospectionResult 作为身份。
+// 结果会在多个请求之间共享缓存，业务代码不应修改它。
+type IntrospectionResult struct {
+	Active    bool        `json:"active"`
+	Scope     string      `json:"scope,omitempty"`
+	ClientID  string      `json:"client_id,omitempty"`
+	Username  string      `json:"username,omitempty"`
+	TokenType string      `json:"token_type,omitempty"`
+	ExpiresAt NumericDate `json:"exp,omitempty"`
+	IssuedAt  NumericDate `json:"iat,omitempty"`
+	NotBefore NumericDate `json:"nbf,omitempty"`
+	Subject   string      `json:"sub,omitempty"`
+	Audience  Audience    `json:"aud,omitempty"`
+	Issuer    string      `json:"iss,omitempty"`
+	ID        string      `json:"jti,omitempty"`
+
+	// Claims 完整的响应字段，包括授权服务器返回的扩展字段 (如 roles)。
+	Claims map[string]any `json:"-"`
+}
+
+// IdentitySubject 实现 SubjectHolder，日志中只记录 Subject (没有时为 ClientID)，不记录完整的 Claims。
+func (r *IntrospectionResult) IdentitySubject() string {
+	if r.Subject != "" {
+		return r.Subject
+	}
+	return r.ClientID
+}
+
+// HasScope 判断 Token 是否被授予了 scope (scope 为空格分隔的列表)。
+func (r *IntrospectionResult) HasScope(scope string) bool {
+	for s := range strings.FieldsSeq(r.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IntrospectionOptions 配置 RFC 7662 Token 内省。
+type IntrospectionOptions struct {
+	// Endpoint 授权服务器的内省端点 URL。必填。
+	Endpoint string
+
+	// ClientID/ClientSecret 访问内省端点的客户端凭证，以 client_secret_basic 方式发送。
+	// 需要 mTLS 或其他认证方式时留空，并通过 Client 的 Transport 实现。
+	ClientID     string
+	ClientSecret string
+
+	// Client 发送内省请求的 HTTP 客户端。默认带 10s 超时。
+	Client *http.Client
+
+	// Timeout 单次内省调用的最长时间，与 Client 的配置无关。默认 10s。
+	// 同一 Token 的并发请求等待同一次调用，因此调用必须有上限。
+	Timeout time.Duration
+
+	// TokenTypeHint 发送给授权服务器的 token_type_hint。默认 "access_token"。
+	TokenTypeHint string
+
+	// Audience 非空时要求响应的 aud 至少包含其中一个值。
+	Audience []string
+
+	// Realm 用于 WWW-Authenticate 的 realm。默认 "api"。
+	Realm string
+
+	// CacheSize 缓存的最大条目数。默认 10000。
+	CacheSize int
+
+	// MaxCacheTTL 有效结果的最长缓存时间。有效结果默认缓存到 exp；
+	// 设置该值可以让吊销更快生效。没有 exp 的结果仅在设置了 MaxCacheTTL 时缓存。
+	MaxCacheTTL time.Duration
+
+	// NegativeCacheTTL 无效结果 (active=false) 的缓存时间，
+	// 防止同一个无效 Token 的反复请求压垮授权服务器。默认 0，不缓存。
+	NegativeCacheTTL time.Duration
+}
+
+// IntrospectionAuth 创建一个通过 RFC 7662 内省端点验证不透明 Bearer Token 的认证策略。
+// 未携带 Token 时返回 ErrNoCredentials (可继续 AuthChain)；
+// Token 无效时返回带 WWW-Authenticate: Bearer error="invalid_token" 的 401；
+// 内省端点不可用时返回原始错误，由 Auth 记录到 Context 中，交给 AuthRequired 处理。
+//
+// 结果按 Token 的 SHA-256 缓存 (不在内存中保存原始 Token)，
+// 同一 Token 的并发请求只会触发一次内省调用。
+func IntrospectionAuth(opts IntrospectionOptions) AuthStrategy {
+	i := newIntrospector(opts)
+	return FromHeader("Bearer", func(ctx context.Context, token string) (any, error) {
+		res, err := i.introspect(ctx, token)
+		if err != nil {
+			if isTokenError(err) || errors.Is(err, ErrTokenInactive) {
+				return nil, invalidTokenError(i.opts.Realm, err)
+			}
+			return nil, err
+		}
+		return res, nil
+	})
+}
+
+type introspectionEntry struct {
+	result  *IntrospectionResult
+	expires time.Time
+}
+
+// introspectionCall 是一次进行中的内省调用，同一 Token 的并发请求共享其结果
+type introspectionCall struct {
+	done   chan struct{}
+	result *IntrospectionResult
+	err    error
+}
+
+type introspector struct {
+	opts  IntrospectionOptions
+	cache *xsync.Map[string, introspectionEntry]
+	calls *xsync.Map[string, *introspectionCall]
+	ops   atomic.Uint64
+}
+
+func newIntrospector(opts IntrospectionOptions) *introspector {
+	if opts.Endpoint == "" {
+		panic("httpx: IntrospectionOptions.Endpoint is required")
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.TokenTypeHint == "" {
+		opts.TokenTypeHint = "access_token"
+	}
+	if opts.Realm == "" {
+		opts.Realm = "api"
+	}
+	if opts.CacheSize <= 0 {
+		opts.CacheSize = 10000
+	}
+	return &introspector{
+		opts:  opts,
+		cache: xsync.NewMap[string, introspectionEntry](),
+		calls: xsync.NewMap[string, *introspectionCall](),
+	}
+}
+
+// introspect 返回 Token 的有效内省结果，或 ErrTokenInactive/ErrToken* 等错误。
+func (i *introspector) introspect(ctx context.Context, token string) (*IntrospectionResult, error) {
+	if len(token) > maxTokenSize {
+		return nil, ErrTokenMalformed
+	}
+	sum := sha256.Sum256([]byte(token))
+	key := string(sum[:])
+
+	res, err := i.lookup(ctx, key, token)
+	if err != nil {
+		return nil, err
+	}
+	return res, i.validate(res)
+}
+
+func (i *introspector) lookup(ctx context.Context, key, token string) (*IntrospectionResult, error) {
+	if e, ok := i.cache.Load(key); ok && time.Now().Before(e.expires) {
+		return e.result, nil
+	}
+
+	call, loaded := i.calls.LoadOrCompute(key, func() (*introspectionCall, bool) {
+		return &introspectionCall{done: make(chan struct{})}, false
+	})
+	if !loaded {
+		// 在独立的 goroutine 中调用，发起者取消请求不会影响等待同一结果的其他请求；
+		// 调用时长由 run 中的 Timeout 限制
+		go i.run(context.WithoutCancel(ctx), key, token, call)
+	}
+
+	select {
+	case <-call.done:
+		return call.result, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (i *introspector) run(ctx context.Context, key, token string, call *introspectionCall) {
+	ctx, cancel := context.WithTimeout(ctx, i.opts.Timeout)
+	defer cancel()
+	call.result, call.err = i.fetch(ctx, token)
+	if call.err == nil {
+		i.store(key, call.result)
+	}
+	// 先写入缓存再移除 call，保证新请求总能命中其中之一
+	i.calls.Delete(key)
+	close(call.done)
+}
+
+func (i *introspector) fetch(ctx context.Context, token string) (*IntrospectionResult, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {i.opts.TokenTypeHint}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.opts.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if i.opts.ClientID != "" {
+		// RFC 6749 §2.3.1: 凭证在 Base64 之前先做 form-urlencoded
+		req.SetBasicAuth(url.QueryEscape(i.opts.ClientID), url.QueryEscape(i.opts.ClientSecret))
+	}
+
+	resp, err := i.opts.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("httpx: introspect token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("httpx: introspect token: unexpected status %d from %s", resp.StatusCode, i.opts.Endpoint)
+	}
+	// 内省响应通常只有几百字节，1MB 的上限足以防止异常响应耗尽内存
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("httpx: introspect token: %w", err)
+	}
+
+	var res IntrospectionResult
+	if err := sonic.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("httpx: parse introspection response: %w", err)
+	}
+	if err := sonic.Unmarshal(data, &res.Claims); err != nil {
+		return nil, fmt.Errorf("httpx: parse introspection response: %w", err)
+	}
+	return &res, nil
+}
+
+// store 按结果的有效期写入缓存：有效结果缓存到 exp (受 MaxCacheTTL 限制)，无效结果缓存 NegativeCacheTTL
+func (i *introspector) store(key string, res *IntrospectionResult) {
+	now := time.Now()
+	var expires time.Time
+	if res.Active {
+		if res.ExpiresAt != 0 {
+			expires = res.ExpiresAt.Time()
+		}
+		if i.opts.MaxCacheTTL > 0 {
+			if limit := now.Add(i.opts.MaxCacheTTL); expires.IsZero() || limit.Before(expires) {
+				expires = limit
+			}
+		}
+	} else if i.opts.NegativeCacheTTL > 0 {
+		expires = now.Add(i.opts.NegativeCacheTTL)
+	}
+	if !now.Before(expires) {
+		return
+	}
+
+	// 每 1024 次写入或缓存已满时清理过期条目
+	if i.ops.Add(1)%1024 == 0 || i.cache.Size() >= i.opts.CacheSize {
+		i.purge(now)
+	}
+	i.cache.Store(key, introspectionEntry{result: res, expires: expires})
+}
+
+// purge 删除过期条目；仍然超出容量时随机淘汰，直到只占用 7/8 的容量，以分摊清理开销
+func (i *introspector) purge(now time.Time) {
+	i.cache.Range(func(k string, e introspectionEntry) bool {
+		if !now.Before(e.expires) {
+			i.cache.Delete(k)
+		}
+		return true
+	})
+	excess := i.cache.Size() - i.opts.CacheSize*7/8
+	if excess <= 0 {
+		return
+	}
+	i.cache.Range(func(k string, _ introspectionEntry) bool {
+		i.cache.Delete(k)
+		excess--
+		return excess > 0
+	})
+}
+
+// validate 在本地复核有效期与受众：缓存的结果可能已经过期
+func (i *introspector) validate(res *IntrospectionResult) error {
+	if !res.Active {
+		return ErrTokenInactive
+	}
+	now := time.Now()
+	if res.ExpiresAt != 0 && !now.Before(res.ExpiresAt.Time()) {
+		return ErrTokenExpired
+	}
+	if res.NotBefore != 0 && now.Before(res.NotBefore.Time()) {
+		return ErrTokenNotYetValid
+	}
+	if len(i.opts.Audience) > 0 && !slices.ContainsFunc(res.Audience, func(aud string) bool {
+		return slices.Contains(i.opts.Audience, aud)
+	}) {
+		return ErrTokenAudience
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAuthorizationServer 模拟 RFC 7662 内省端点，返回 tokens 中登记的响应
+type testAuthorizationServer struct {
+	*httptest.Server
+	calls  atomic.Int32
+	tokens map[string]map[string]any
+	gate   chan struct{} // 非 nil 时阻塞响应，直到被关闭
+}
+
+func newTestAuthorizationServer(t *testing.T, tokens map[string]map[string]any) *testAuthorizationServer {
+	as := &testAuthorizationServer{tokens: tokens}
+	as.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		as.calls.Add(1)
+		if as.gate != nil {
+			<-as.gate
+		}
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "resource-server" || pass != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "access_token", r.PostFormValue("token_type_hint"))
+		resp, ok := as.tokens[r.PostFormValue("token")]
+		if !ok {
+			resp = map[string]any{"active": false}
+		}
+		data, _ := sonic.Marshal(resp)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(as.Close)
+	return as
+}
+
+func introspectionHandler(opts IntrospectionOptions) http.Handler {
+	return Auth(IntrospectionAuth(opts))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if res, ok := IdentityAs[*IntrospectionResult](r.Context()); ok {
+			w.Write([]byte(res.Subject + ":" + res.Claims["tenant"].(string)))
+			return
+		}
+		if err := AuthErrorFrom(r.Context()); err != nil && !errors.Is(err, ErrNoCredentials) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+func serveBearer(h http.Handler, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", "/", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestIntrospectionAuth(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	as := newTestAuthorizationServer(t, map[string]map[string]any{
+		"good":    {"active": true, "sub": "user-1", "scope": "read write", "aud": "api", "exp": exp, "tenant": "acme"},
+		"expired": {"active": true, "sub": "user-2", "exp": time.Now().Add(-time.Minute).Unix(), "tenant": "acme"},
+		"foreign": {"active": true, "sub": "user-3", "aud": []string{"other"}, "exp": exp, "tenant": "acme"},
+	})
+	opts := IntrospectionOptions{
+		Endpoint:         as.URL,
+		ClientID:         "resource-server",
+		ClientSecret:     "s3cret",
+		Audience:         []string{"api"},
+		NegativeCacheTTL: time.Minute,
+	}
+	h := introspectionHandler(opts)
+
+	t.Run("ActiveCached", func(t *testing.T) {
+		as.calls.Store(0)
+		for range 3 {
+			w := serveBearer(h, "good")
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "user-1:acme", w.Body.String())
+		}
+		assert.Equal(t, int32(1), as.calls.Load())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, token := range []string{"unknown", "expired", "foreign"} {
+			w := serveBearer(h, token)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, token)
+			assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="invalid_token"`, token)
+		}
+	})
+
+	t.Run("NegativeCache", func(t *testing.T) {
+		as.calls.Store(0)
+		serveBearer(h, "bogus")
+		serveBearer(h, "bogus")
+		assert.Equal(t, int32(1), as.calls.Load())
+
+		noNegative := introspectionHandler(IntrospectionOptions{Endpoint: as.URL, ClientID: "resource-server", ClientSecret: "s3cret"})
+		as.calls.Store(0)
+		serveBearer(noNegative, "bogus")
+		serveBearer(noNegative, "bogus")
+		assert.Equal(t, int32(2), as.calls.Load())
+	})
+
+	t.Run("NoCredentials", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, serveBearer(h, "").Code)
+	})
+
+	t.Run("ServerUnavailable", func(t *testing.T) {
+		bad := introspectionHandler(IntrospectionOptions{Endpoint: as.URL, ClientID: "resource-server", ClientSecret: "wrong"})
+		assert.Equal(t, http.StatusServiceUnavailable, serveBearer(bad, "good").Code)
+	})
+
+	t.Run("Scope", func(t *testing.T) {
+		h := Auth(IntrospectionAuth(opts))(Require(HasScope("write"))(http.NotFoundHandler()))
+		assert.Equal(t, http.StatusNotFound, serveBearer(h, "good").Code)
+		h = Auth(IntrospectionAuth(opts))(Require(HasScope("admin"))(http.NotFoundHandler()))
+		assert.Equal(t, http.StatusForbidden, serveBearer(h, "good").Code)
+	})
+}
+
+func TestIntrospectionResult_IdentitySubject(t *testing.T) {
+	user := &IntrospectionResult{Active: true, Subject: "user-1", ClientID: "web", Claims: map[string]any{"tenant": "acme"}}
+	assert.Equal(t, "user-1", user.IdentitySubject())
+
+	// client_credentials 签发的 Token 通常没有 sub，退回 client_id
+	service := &IntrospectionResult{Active: true, ClientID: "svc"}
+	assert.Equal(t, "svc", service.IdentitySubject())
+}
+
+func TestIntrospectionAuth_Coalescing(t *testing.T) {
+	as := newTestAuthorizationServer(t, map[string]map[string]any{
+		"good": {"active": true, "sub": "user-1", "tenant": "acme"},
+	})
+	as.gate = make(chan struct{})
+	h := introspectionHandler(IntrospectionOptions{Endpoint: as.URL, ClientID: "resource-server", ClientSecret: "s3cret"})
+
+	var wg sync.WaitGroup
+	codes := make([]int, 20)
+	for n := range codes {
+		wg.Go(func() { codes[n] = serveBearer(h, "good").Code })
+	}
+	require.Eventually(t, func() bool { return as.calls.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond) // 让其余请求进入等待
+	close(as.gate)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), as.calls.Load())
+	for _, code := range codes {
+		assert.Equal(t, http.StatusOK, code)
+	}
+
+	// 没有 exp 且未设置 MaxCacheTTL 的结果不缓存
+	serveBearer(h, "good")
+	assert.Equal(t, int32(2), as.calls.Load())
+
+	// 等待者取消请求时立即返回，进行中的调用在后台完成
+	as.gate = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	i := newIntrospector(IntrospectionOptions{Endpoint: as.URL, ClientID: "resource-server", ClientSecret: "s3cret"})
+	cancel()
+	_, err := i.introspect(ctx, "good")
+	assert.ErrorIs(t, err, context.Canceled)
+	close(as.gate)
+}
+
+func TestIntrospectionAuth_Timeout(t *testing.T) {
+	as := newTestAuthorizationServer(t, map[string]map[string]any{
+		"good": {"active": true, "sub": "user-1", "tenant": "acme"},
+	})
+	as.gate = make(chan struct{})
+	// 没有超时的 Client 也不会让调用永远挂起
+	h := introspectionHandler(IntrospectionOptions{
+		Endpoint: as.URL, ClientID: "resource-server", ClientSecret: "s3cret",
+		Client: &http.Client{}, Timeout: 50 * time.Millisecond,
+	})
+
+	assert.Equal(t, http.StatusServiceUnavailable, serveBearer(h, "good").Code)
+	close(as.gate)
+	// 超时的调用已被移除，后续请求重新内省
+	assert.Equal(t, http.StatusOK, serveBearer(h, "good").Code)
+	assert.Equal(t, int32(2), as.calls.Load())
+}
+
+func TestIntrospector_Cache(t *testing.T) {
+	i := newIntrospector(IntrospectionOptions{Endpoint: "http://as.invalid", CacheSize: 100, MaxCacheTTL: time.Minute})
+	for n := range 1000 {
+		i.store(string(rune(n)), &IntrospectionResult{Active: true})
+	}
+	assert.LessOrEqual(t, i.cache.Size(), 100)
+
+	i.cache.Clear()
+	i.store("short", &IntrospectionResult{Active: true, ExpiresAt: NumericDate(time.Now().Add(10 * time.Second).Unix())})
+	i.store("capped", &IntrospectionResult{Active: true, ExpiresAt: NumericDate(time.Now().Add(time.Hour).Unix())})
+	i.store("inactive", &IntrospectionResult{Active: false})
+	short, _ := i.cache.Load("short")
+	capped, _ := i.cache.Load("capped")
+	assert.WithinDuration(t, time.Now().Add(10*time.Second), short.expires, 2*time.Second)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), capped.expires, 2*time.Second)
+	_, ok := i.cache.Load("inactive")
+	assert.False(t, ok)
+}
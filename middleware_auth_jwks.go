@@ -0,0 +1,346 @@
+package httpx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+// JWKSSource 获取 JWKS 文档 (RFC 7517) 的原始 JSON。
+// 通过替换 Source，可以从远程 URL、本地文件或测试用的 httptest.Server 加载密钥。
+type JWKSSource func(ctx context.Context) ([]byte, error)
+
+// JWKSFromURL 通过 HTTP GET 拉取 JWKS。client 为 nil 时使用带 10s 超时的默认客户端。
+func JWKSFromURL(url string, client *http.Client) JWKSSource {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+		}
+		// JWKS 文档通常只有几 KB，1MB 的上限足以防止异常响应耗尽内存
+		return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	}
+}
+
+// JWKSFromFile 从本地文件读取 JWKS。
+func JWKSFromFile(path string) JWKSSource {
+	return func(ctx context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}
+}
+
+// JWKSOptions 配置 JWKS 的缓存行为。
+type JWKSOptions struct {
+	// TTL 缓存的有效期，过期后下次查找时重新拉取。默认 10 分钟。
+	TTL time.Duration
+
+	// MinRefreshInterval 遇到未知 kid 时触发刷新 (应对密钥轮换) 的最小间隔，
+	// 防止攻击者用随机 kid 放大对 JWKS 端点的请求。默认 1 分钟。
+	MinRefreshInterval time.Duration
+
+	// AllowSymmetricKeys 是否接受 kty=oct 的对称 (HMAC) 密钥，默认忽略。
+	// 对称密钥同时可以签发 Token，能发布 JWKS 文档的任何人都将拥有它；
+	// 只应对受信任的本地文件等来源开启。
+	AllowSymmetricKeys bool
+}
+
+// JWKS 是一个带缓存的 KeySet，从 JWKSSource 加载密钥。
+// 拉取失败时继续使用旧的密钥 (如果有)；并发的刷新共享同一次拉取。
+type JWKS struct {
+	source JWKSSource
+	opts   JWKSOptions
+
+	mu        sync.RWMutex
+	keys      map[string]jwk
+	fetchedAt time.Time
+	// refreshedAt 与 err 记录最近一次拉取 (无论成功与否) 的开始时间与结果，
+	// 首次加载失败后同样按 MinRefreshInterval 限频
+	refreshedAt time.Time
+	err         error
+	call        *jwksCall
+}
+
+// jwksCall 是一次进行中的拉取，等待中的请求共享其结果
+type jwksCall struct {
+	done chan struct{}
+	err  error
+}
+
+// jwksFetchTimeout 限制单次拉取的时长，与 JWKSSource 的实现无关
+const jwksFetchTimeout = 10 * time.Second
+
+type jwk struct {
+	alg string
+	key any
+}
+
+// NewJWKS 创建一个 JWKS KeySet。密钥在第一次查找时懒加载。
+func NewJWKS(source JWKSSource, opts ...JWKSOptions) *JWKS {
+	var opt JWKSOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.TTL <= 0 {
+		opt.TTL = 10 * time.Minute
+	}
+	if opt.MinRefreshInterval <= 0 {
+		opt.MinRefreshInterval = time.Minute
+	}
+	return &JWKS{source: source, opts: opt}
+}
+
+// Key 实现 KeySet。
+func (j *JWKS) Key(ctx context.Context, kid, alg string) (any, error) {
+	j.mu.RLock()
+	keys, fetchedAt, refreshedAt, lastErr := j.keys, j.fetchedAt, j.refreshedAt, j.err
+	j.mu.RUnlock()
+
+	now := time.Now()
+	throttled := now.Sub(refreshedAt) < j.opts.MinRefreshInterval
+	if keys != nil {
+		key, ok := lookupJWK(keys, kid, alg)
+		if ok && now.Sub(fetchedAt) <= j.opts.TTL {
+			return key, nil
+		}
+		// 缓存过期或遇到未知 kid (可能是密钥轮换) 时刷新，但需限频；
+		// 刷新过于频繁时 (例如 JWKS 端点持续故障) 继续使用旧密钥
+		if throttled {
+			if ok {
+				return key, nil
+			}
+			return nil, ErrTokenKeyNotFound
+		}
+	} else if throttled && lastErr != nil {
+		// 从未成功加载过：端点故障期间直接返回上次的错误，而不是让每个请求都去拉取
+		return nil, lastErr
+	}
+
+	// 拉取失败时沿用旧密钥，只有从未成功加载过才报错
+	err := j.refresh(ctx, refreshedAt)
+	j.mu.RLock()
+	keys = j.keys
+	j.mu.RUnlock()
+	if keys == nil {
+		return nil, err
+	}
+	if key, ok := lookupJWK(keys, kid, alg); ok {
+		return key, nil
+	}
+	return nil, ErrTokenKeyNotFound
+}
+
+// Refresh 立即重新拉取 JWKS。如果已有拉取在进行中，则等待其结果。
+func (j *JWKS) Refresh(ctx context.Context) error {
+	j.mu.RLock()
+	refreshedAt := j.refreshedAt
+	j.mu.RUnlock()
+	return j.refresh(ctx, refreshedAt)
+}
+
+// refresh 加入进行中的拉取，或发起新的拉取。seen 是调用方观察到的 refreshedAt，
+// 如果此后已有其他请求完成了拉取，则直接返回其结果。
+func (j *JWKS) refresh(ctx context.Context, seen time.Time) error {
+	j.mu.Lock()
+	call := j.call
+	if call == nil {
+		if !j.refreshedAt.Equal(seen) {
+			err := j.err
+			j.mu.Unlock()
+			return err
+		}
+		call = &jwksCall{done: make(chan struct{})}
+		j.call = call
+		j.refreshedAt = time.Now()
+		// 在独立的 goroutine 中拉取，发起者取消请求不会影响等待同一结果的其他请求
+		go j.run(context.WithoutCancel(ctx), call, j.refreshedAt)
+	}
+	j.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (j *JWKS) run(ctx context.Context, call *jwksCall, start time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+
+	var keys map[string]jwk
+	data, err := j.source(ctx)
+	if err != nil {
+		err = fmt.Errorf("httpx: fetch JWKS: %w", err)
+	} else {
+		keys, err = parseJWKS(data, j.opts.AllowSymmetricKeys)
+	}
+
+	j.mu.Lock()
+	if err == nil {
+		j.keys = keys
+		j.fetchedAt = start
+	}
+	j.err = err
+	j.call = nil
+	call.err = err
+	j.mu.Unlock()
+	close(call.done)
+}
+
+func lookupJWK(keys map[string]jwk, kid, alg string) (any, bool) {
+	if k, ok := keys[kid]; ok && (k.alg == "" || k.alg == alg) {
+		return k.key, true
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, k := range keys {
+			if k.alg == "" || k.alg == alg {
+				return k.key, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// parseJWKS 解析 JWKS 文档，返回 kid -> 公钥。
+// 支持 RSA、EC (P-256/P-384/P-521)、OKP (Ed25519) 与 oct (HMAC，仅当 allowOct 时) 密钥；
+// 用途为加密 (use=enc) 的密钥和无法识别的密钥会被忽略。
+func parseJWKS(data []byte, allowOct bool) (map[string]jwk, error) {
+	var doc struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			Crv string `json:"crv"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+			K   string `json:"k"`
+		} `json:"keys"`
+	}
+	if err := sonic.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("httpx: parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]jwk, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+		var key any
+		var err error
+		switch k.Kty {
+		case "RSA":
+			key, err = parseRSAJWK(k.N, k.E)
+		case "EC":
+			if !slices.Contains(supportedJWKCurves, k.Crv) {
+				continue
+			}
+			key, err = parseECJWK(k.Crv, k.X, k.Y)
+		case "OKP":
+			if k.Crv != "Ed25519" {
+				continue
+			}
+			var x []byte
+			x, err = base64.RawURLEncoding.DecodeString(k.X)
+			if err == nil && len(x) != ed25519.PublicKeySize {
+				err = fmt.Errorf("bad Ed25519 key size %d", len(x))
+			}
+			key = ed25519.PublicKey(x)
+		case "oct":
+			if !allowOct {
+				continue
+			}
+			key, err = base64.RawURLEncoding.DecodeString(k.K)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("httpx: parse JWK %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = jwk{alg: k.Alg, key: key}
+	}
+	return keys, nil
+}
+
+// supportedJWKCurves 是 parseECJWK 支持的曲线，其他曲线 (如 secp256k1) 的密钥会被忽略
+var supportedJWKCurves = []string{"P-256", "P-384", "P-521"}
+
+func parseRSAJWK(n, e string) (*rsa.PublicKey, error) {
+	nb, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, err
+	}
+	eb, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, err
+	}
+	if len(eb) == 0 || len(eb) > 4 {
+		return nil, fmt.Errorf("bad RSA exponent")
+	}
+	exp := 0
+	for _, b := range eb {
+		exp = exp<<8 | int(b)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: exp}, nil
+}
+
+func parseECJWK(crv, x, y string) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", crv)
+	}
+	xb, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, err
+	}
+	yb, err := base64.RawURLEncoding.DecodeString(y)
+	if err != nil {
+		return nil, err
+	}
+
+	// 以未压缩点格式 (0x04 || X || Y) 解析，同时校验点在曲线上
+	size := (curve.Params().BitSize + 7) / 8
+	if len(xb) > size || len(yb) > size {
+		return nil, fmt.Errorf("bad EC coordinate size")
+	}
+	point := make([]byte, 1+2*size)
+	point[0] = 4
+	copy(point[1+size-len(xb):1+size], xb)
+	copy(point[1+2*size-len(yb):], yb)
+	return ecdsa.ParseUncompressedPublicKey(curve, point)
+}
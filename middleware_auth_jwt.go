@@ -0,0 +1,428 @@
+package httpx
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "crypto/sha256" // 注册 SHA-256 供 crypto.Hash 使用
+	_ "crypto/sha512" // 注册 SHA-384/512 供 crypto.Hash 使用
+
+	"github.com/bytedance/sonic"
+)
+
+// JWT 验证失败的原因。策略会把它们转换为带 WWW-Authenticate 的 401，
+// 直接使用 JWTValidator 时可通过 errors.Is 判断。
+var (
+	ErrTokenMalformed    = errors.New("token is malformed")
+	ErrTokenAlgorithm    = errors.New("token algorithm is not allowed")
+	ErrTokenSignature    = errors.New("token signature is invalid")
+	ErrTokenExpired      = errors.New("token is expired")
+	ErrTokenNoExpiry     = errors.New("token has no expiration time")
+	ErrTokenNotYetValid  = errors.New("token is not valid yet")
+	ErrTokenIssuer       = errors.New("token issuer is invalid")
+	ErrTokenAudience     = errors.New("token audience is invalid")
+	ErrTokenKeyNotFound  = errors.New("token signing key not found")
+	ErrTokenUnverifiable = errors.New("token is unverifiable")
+)
+
+// maxTokenSize 拒绝超大的 Token，避免 base64/JSON 解码放大开销
+const maxTokenSize = 16 << 10
+
+// KeySet 按 Token 头部的 kid 与 alg 查找验签公钥 (或 HMAC 密钥)。
+// 返回的 key 类型必须与 alg 匹配：HS* 为 []byte，RS*/PS* 为 *rsa.PublicKey，
+// ES* 为 *ecdsa.PublicKey，EdDSA 为 ed25519.PublicKey。
+type KeySet interface {
+	Key(ctx context.Context, kid, alg string) (any, error)
+}
+
+// StaticKeys 是固定的 kid -> key 映射。
+// Token 未携带 kid 且集合中只有一个 key 时，使用该 key。
+type StaticKeys map[string]any
+
+func (s StaticKeys) Key(ctx context.Context, kid, alg string) (any, error) {
+	if key, ok := s[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(s) == 1 {
+		for _, key := range s {
+			return key, nil
+		}
+	}
+	return nil, ErrTokenKeyNotFound
+}
+
+// JWTOptions 配置 JWT 验证。
+type JWTOptions struct {
+	// Keys 验签密钥来源，如 StaticKeys 或 *JWKS。必填。
+	Keys KeySet
+
+	// Algorithms 允许的签名算法。默认允许全部支持的算法
+	// (HS256/384/512, RS256/384/512, PS256/384/512, ES256/384/512, EdDSA)。
+	// 无论如何配置，key 的类型都必须与算法匹配，因此不存在 RS/HS 混淆攻击。
+	Algorithms []string
+
+	// Issuer 非空时要求 iss 与之相等。
+	Issuer string
+
+	// Audience 非空时要求 aud 包含其中之一。
+	Audience []string
+
+	// ClockSkew 校验 exp/nbf 时允许的时钟偏差。
+	ClockSkew time.Duration
+
+	// AllowMissingExpiry 是否接受不带 exp 的 Token。默认拒绝 (ErrTokenNoExpiry)，
+	// 因为这样的 Token 一旦泄露便永久有效。
+	AllowMissingExpiry bool
+
+	// Cookie 非空时，在 Authorization 头缺失的情况下从该 Cookie 读取 Token (通过 FromCookie)。
+	Cookie string
+
+	// Realm 用于 WWW-Authenticate 头。默认 "api"。
+	Realm string
+}
+
+// NumericDate 是 JWT 中以秒为单位的时间戳 (RFC 7519 §2)，允许小数。
+type NumericDate int64
+
+func (d *NumericDate) UnmarshalJSON(b []byte) error {
+	f, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return fmt.Errorf("httpx: invalid NumericDate %s", b)
+	}
+	*d = NumericDate(f)
+	return nil
+}
+
+// Time 将 NumericDate 转换为 time.Time。
+func (d NumericDate) Time() time.Time { return time.Unix(int64(d), 0) }
+
+// Audience 是 aud 声明，兼容单个字符串与字符串数组两种编码。
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := sonic.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*a = Audience{s}
+		return nil
+	}
+	var list []string
+	if err := sonic.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return sonic.Marshal(a[0])
+	}
+	return sonic.Marshal([]string(a))
+}
+
+// RegisteredClaims 是 RFC 7519 注册的声明，可嵌入到自定义的 Claims 结构体中。
+type RegisteredClaims struct {
+	Issuer    string      `json:"iss,omitempty"`
+	Subject   string      `json:"sub,omitempty"`
+	Audience  Audience    `json:"aud,omitempty"`
+	ExpiresAt NumericDate `json:"exp,omitempty"`
+	NotBefore NumericDate `json:"nbf,omitempty"`
+	IssuedAt  NumericDate `json:"iat,omitempty"`
+	ID        string      `json:"jti,omitempty"`
+}
+
+// IdentitySubject 实现 SubjectHolder，日志中只记录 Subject。
+// 嵌入 RegisteredClaims 的 Claims 结构体会继承该方法，因此访问日志不会输出完整的 Claims。
+func (c RegisteredClaims) IdentitySubject() string {
+	return c.Subject
+}
+
+type jwtHeader struct {
+	Alg  string   `json:"alg"`
+	Kid  string   `json:"kid"`
+	Crit []string `json:"crit"`
+}
+
+// jwtVerifier 持有归一化后的配置
+type jwtVerifier struct {
+	opts JWTOptions
+}
+
+func newJWTVerifier(opts JWTOptions) *jwtVerifier {
+	if opts.Keys == nil {
+		panic("httpx: JWTOptions.Keys is required")
+	}
+	if opts.Realm == "" {
+		opts.Realm = "api"
+	}
+	return &jwtVerifier{opts: opts}
+}
+
+// verify 校验签名与注册声明，并将 payload 解码到 claims。
+func (v *jwtVerifier) verify(ctx context.Context, token string, claims any) error {
+	if len(token) > maxTokenSize {
+		return ErrTokenMalformed
+	}
+	header, payload, sig, signed, err := splitJWT(token)
+	if err != nil {
+		return err
+	}
+
+	var h jwtHeader
+	if err := sonic.Unmarshal(header, &h); err != nil {
+		return ErrTokenMalformed
+	}
+	if len(h.Crit) > 0 {
+		// 我们不理解任何扩展头，按 RFC 7515 §4.1.11 必须拒绝
+		return ErrTokenUnverifiable
+	}
+	if h.Alg == "" || h.Alg == "none" || (len(v.opts.Algorithms) > 0 && !slices.Contains(v.opts.Algorithms, h.Alg)) {
+		return ErrTokenAlgorithm
+	}
+
+	key, err := v.opts.Keys.Key(ctx, h.Kid, h.Alg)
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(h.Alg, key, signed, sig); err != nil {
+		return err
+	}
+
+	var rc RegisteredClaims
+	if err := sonic.Unmarshal(payload, &rc); err != nil {
+		return ErrTokenMalformed
+	}
+	if err := v.validateClaims(&rc); err != nil {
+		return err
+	}
+	if err := sonic.Unmarshal(payload, claims); err != nil {
+		return ErrTokenMalformed
+	}
+	return nil
+}
+
+func (v *jwtVerifier) validateClaims(c *RegisteredClaims) error {
+	now := time.Now()
+	skew := v.opts.ClockSkew
+	if c.ExpiresAt == 0 {
+		if !v.opts.AllowMissingExpiry {
+			return ErrTokenNoExpiry
+		}
+	} else if !now.Before(c.ExpiresAt.Time().Add(skew)) {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != 0 && now.Add(skew).Before(c.NotBefore.Time()) {
+		return ErrTokenNotYetValid
+	}
+	if v.opts.Issuer != "" && c.Issuer != v.opts.Issuer {
+		return ErrTokenIssuer
+	}
+	if len(v.opts.Audience) > 0 && !slices.ContainsFunc(c.Audience, func(aud string) bool {
+		return slices.Contains(v.opts.Audience, aud)
+	}) {
+		return ErrTokenAudience
+	}
+	return nil
+}
+
+// challenge 将 Token 本身的验证失败转换为 RFC 6750 的 invalid_token 响应。
+// 其他错误 (如 JWKS 拉取失败) 原样返回，由 Auth 记录到 Context 中，交给 AuthRequired 处理。
+func (v *jwtVerifier) challenge(err error) error {
+	if !isTokenError(err) {
+		return err
+	}
+	return invalidTokenError(v.opts.Realm, err)
+}
+
+// invalidTokenError 构造 RFC 6750 §3.1 的 invalid_token 响应
+func invalidTokenError(realm string, err error) *HttpError {
+	return &HttpError{
+		HttpCode: http.StatusUnauthorized,
+		BizCode:  CodeUnauthorized,
+		Msg:      err.Error(),
+		Header: http.Header{"Www-Authenticate": {
+			`Bearer realm="` + realm + `", error="invalid_token", error_description="` + err.Error() + `"`,
+		}},
+	}
+}
+
+var tokenErrors = []error{
+	ErrTokenMalformed, ErrTokenAlgorithm, ErrTokenSignature, ErrTokenExpired, ErrTokenNoExpiry, ErrTokenNotYetValid,
+	ErrTokenIssuer, ErrTokenAudience, ErrTokenKeyNotFound, ErrTokenUnverifiable,
+}
+
+func isTokenError(err error) bool {
+	for _, target := range tokenErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// JWTValidator 返回一个验证 JWT 的 validator，可与 FromHeader/FromCookie/FromQuery 自由组合。
+// 验证成功时返回 *C 作为身份；失败时返回原始错误 (ErrToken*)。
+func JWTValidator[C any](opts JWTOptions) func(context.Context, string) (any, error) {
+	v := newJWTVerifier(opts)
+	return func(ctx context.Context, token string) (any, error) {
+		claims := new(C)
+		if err := v.verify(ctx, token, claims); err != nil {
+			return nil, err
+		}
+		return claims, nil
+	}
+}
+
+// JWT 创建一个 Bearer JWT 认证策略。
+// Token 依次从 Authorization: Bearer 头与 opts.Cookie 读取，验证通过后以 *C 作为身份注入。
+// 未携带 Token 时返回 ErrNoCredentials (可继续 AuthChain)；
+// 验证失败时返回带 WWW-Authenticate: Bearer error="invalid_token" 的 401。
+//
+//	type Claims struct {
+//		httpx.RegisteredClaims
+//		Scope string `json:"scope"`
+//	}
+//	mw := httpx.Auth(httpx.JWT[Claims](httpx.JWTOptions{Keys: jwks, Issuer: "https://issuer"}))
+func JWT[C any](opts JWTOptions) AuthStrategy {
+	v := newJWTVerifier(opts)
+	validate := func(ctx context.Context, token string) (any, error) {
+		claims := new(C)
+		if err := v.verify(ctx, token, claims); err != nil {
+			return nil, v.challenge(err)
+		}
+		return claims, nil
+	}
+
+	strategy := FromHeader("Bearer", validate)
+	if opts.Cookie != "" {
+		strategy = AuthChain(strategy, FromCookie(opts.Cookie, validate))
+	}
+	return strategy
+}
+
+// splitJWT 拆分 JWS Compact Serialization，返回解码后的各部分与签名输入
+func splitJWT(token string) (header, payload, sig []byte, signed string, err error) {
+	first := strings.IndexByte(token, '.')
+	last := strings.LastIndexByte(token, '.')
+	if first < 0 || first == last || strings.IndexByte(token[first+1:last], '.') >= 0 {
+		return nil, nil, nil, "", ErrTokenMalformed
+	}
+	enc := base64.RawURLEncoding
+	if header, err = enc.DecodeString(token[:first]); err != nil {
+		return nil, nil, nil, "", ErrTokenMalformed
+	}
+	if payload, err = enc.DecodeString(token[first+1 : last]); err != nil {
+		return nil, nil, nil, "", ErrTokenMalformed
+	}
+	if sig, err = enc.DecodeString(token[last+1:]); err != nil {
+		return nil, nil, nil, "", ErrTokenMalformed
+	}
+	return header, payload, sig, token[:last], nil
+}
+
+// jwtHash 返回算法名称后缀对应的哈希函数 (256/384/512)
+func jwtHash(alg string) (crypto.Hash, bool) {
+	switch alg[len(alg)-3:] {
+	case "256":
+		return crypto.SHA256, true
+	case "384":
+		return crypto.SHA384, true
+	case "512":
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+// verifySignature 校验签名，同时确保 key 的类型与算法族匹配
+func verifySignature(alg string, key any, signed string, sig []byte) error {
+	if alg == "EdDSA" {
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(pub, []byte(signed), sig) {
+			return ErrTokenSignature
+		}
+		return nil
+	}
+	if len(alg) != 5 {
+		return ErrTokenAlgorithm
+	}
+	hash, ok := jwtHash(alg)
+	if !ok {
+		return ErrTokenAlgorithm
+	}
+
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrTokenSignature
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return ErrTokenSignature
+		}
+		return nil
+	}
+
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(pub, hash, digest, sig) != nil {
+			return ErrTokenSignature
+		}
+	case "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) != nil {
+			return ErrTokenSignature
+		}
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		// ES256 必须使用 P-256，ES384 使用 P-384，ES512 使用 P-521
+		if !ok || pub.Curve.Params().BitSize != ecdsaCurveBits(hash) {
+			return ErrTokenSignature
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return ErrTokenSignature
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return ErrTokenSignature
+		}
+	default:
+		return ErrTokenAlgorithm
+	}
+	return nil
+}
+
+func ecdsaCurveBits(hash crypto.Hash) int {
+	switch hash {
+	case crypto.SHA256:
+		return 256
+	case crypto.SHA384:
+		return 384
+	default:
+		return 521
+	}
+}
@@ -0,0 +1,437 @@
+package httpx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testClaims struct {
+	RegisteredClaims
+	Scope string `json:"scope"`
+}
+
+// signTestJWT 使用给定算法签发 JWT，仅用于测试
+func signTestJWT(t *testing.T, alg, kid string, key any, claims any) string {
+	t.Helper()
+	enc := base64.RawURLEncoding
+	header, _ := sonic.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, err := sonic.Marshal(claims)
+	require.NoError(t, err)
+	signed := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+
+	var sig []byte
+	if alg == "EdDSA" {
+		sig = ed25519.Sign(key.(ed25519.PrivateKey), []byte(signed))
+		return signed + "." + enc.EncodeToString(sig)
+	}
+	hash, _ := jwtHash(alg)
+	if alg[:2] == "HS" {
+		mac := hmac.New(hash.New, key.([]byte))
+		mac.Write([]byte(signed))
+		return signed + "." + enc.EncodeToString(mac.Sum(nil))
+	}
+
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+	switch alg[:2] {
+	case "RS":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), hash, digest)
+	case "PS":
+		sig, err = rsa.SignPSS(rand.Reader, key.(*rsa.PrivateKey), hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES":
+		priv := key.(*ecdsa.PrivateKey)
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, priv, digest)
+		size := (priv.Curve.Params().BitSize + 7) / 8
+		sig = make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+	}
+	require.NoError(t, err)
+	return signed + "." + enc.EncodeToString(sig)
+}
+
+func validClaims() testClaims {
+	return testClaims{
+		RegisteredClaims: RegisteredClaims{
+			Issuer:    "https://issuer.example.com",
+			Subject:   "user-1",
+			Audience:  Audience{"api"},
+			ExpiresAt: NumericDate(time.Now().Add(time.Hour).Unix()),
+		},
+		Scope: "read write",
+	}
+}
+
+func TestJWTValidator_Algorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecKey384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edPub, edPriv, _ := ed25519.GenerateKey(rand.Reader)
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	keys := StaticKeys{
+		"hs":  secret,
+		"rsa": &rsaKey.PublicKey,
+		"ec":  &ecKey256.PublicKey,
+		"ec3": &ecKey384.PublicKey,
+		"ed":  edPub,
+	}
+	validate := JWTValidator[testClaims](JWTOptions{Keys: keys})
+
+	tests := []struct {
+		alg, kid string
+		key      any
+	}{
+		{"HS256", "hs", secret},
+		{"HS512", "hs", secret},
+		{"RS256", "rsa", rsaKey},
+		{"RS384", "rsa", rsaKey},
+		{"PS256", "rsa", rsaKey},
+		{"PS512", "rsa", rsaKey},
+		{"ES256", "ec", ecKey256},
+		{"ES384", "ec3", ecKey384},
+		{"EdDSA", "ed", edPriv},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			identity, err := validate(context.Background(), signTestJWT(t, tt.alg, tt.kid, tt.key, validClaims()))
+			require.NoError(t, err)
+			claims := identity.(*testClaims)
+			assert.Equal(t, "user-1", claims.Subject)
+			assert.Equal(t, "read write", claims.Scope)
+		})
+	}
+
+	t.Run("KeyTypeMismatch", func(t *testing.T) {
+		// 攻击者用 RSA 公钥作为 HMAC 密钥签名 (算法混淆攻击)
+		token := signTestJWT(t, "HS256", "rsa", rsaKey.PublicKey.N.Bytes(), validClaims())
+		_, err := validate(context.Background(), token)
+		assert.ErrorIs(t, err, ErrTokenSignature)
+
+		// ES384 的签名不能用 P-256 的密钥验证
+		token = signTestJWT(t, "ES384", "ec", ecKey384, validClaims())
+		_, err = validate(context.Background(), token)
+		assert.ErrorIs(t, err, ErrTokenSignature)
+	})
+
+	t.Run("NoneRejected", func(t *testing.T) {
+		enc := base64.RawURLEncoding
+		token := enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString([]byte(`{"sub":"x"}`)) + "."
+		_, err := validate(context.Background(), token)
+		assert.ErrorIs(t, err, ErrTokenAlgorithm)
+	})
+
+	t.Run("AllowedAlgorithms", func(t *testing.T) {
+		onlyRS := JWTValidator[testClaims](JWTOptions{Keys: keys, Algorithms: []string{"RS256"}})
+		_, err := onlyRS(context.Background(), signTestJWT(t, "HS256", "hs", secret, validClaims()))
+		assert.ErrorIs(t, err, ErrTokenAlgorithm)
+	})
+}
+
+func TestJWTValidator_Claims(t *testing.T) {
+	secret := []byte("secret")
+	validate := JWTValidator[testClaims](JWTOptions{
+		Keys:      StaticKeys{"": secret},
+		Issuer:    "https://issuer.example.com",
+		Audience:  []string{"api", "admin"},
+		ClockSkew: 30 * time.Second,
+	})
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		mutate func(c *testClaims)
+		want   error
+	}{
+		{name: "Valid", mutate: func(c *testClaims) {}},
+		{name: "Expired", mutate: func(c *testClaims) { c.ExpiresAt = NumericDate(now.Add(-time.Minute).Unix()) }, want: ErrTokenExpired},
+		{name: "NoExpiry", mutate: func(c *testClaims) { c.ExpiresAt = 0 }, want: ErrTokenNoExpiry},
+		{name: "ExpiredWithinSkew", mutate: func(c *testClaims) { c.ExpiresAt = NumericDate(now.Add(-10 * time.Second).Unix()) }},
+		{name: "NotYetValid", mutate: func(c *testClaims) { c.NotBefore = NumericDate(now.Add(time.Minute).Unix()) }, want: ErrTokenNotYetValid},
+		{name: "NotBeforeWithinSkew", mutate: func(c *testClaims) { c.NotBefore = NumericDate(now.Add(10 * time.Second).Unix()) }},
+		{name: "WrongIssuer", mutate: func(c *testClaims) { c.Issuer = "https://evil.example.com" }, want: ErrTokenIssuer},
+		{name: "WrongAudience", mutate: func(c *testClaims) { c.Audience = Audience{"other"} }, want: ErrTokenAudience},
+		{name: "AnyAudience", mutate: func(c *testClaims) { c.Audience = Audience{"other", "admin"} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validClaims()
+			tt.mutate(&c)
+			_, err := validate(context.Background(), signTestJWT(t, "HS256", "", secret, c))
+			if tt.want == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("AllowMissingExpiry", func(t *testing.T) {
+		lenient := JWTValidator[testClaims](JWTOptions{Keys: StaticKeys{"": secret}, AllowMissingExpiry: true})
+		c := validClaims()
+		c.ExpiresAt = 0
+		_, err := lenient(context.Background(), signTestJWT(t, "HS256", "", secret, c))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		for _, token := range []string{"", "a.b", "a.b.c.d", "!!.e30.sig"} {
+			_, err := validate(context.Background(), token)
+			assert.ErrorIs(t, err, ErrTokenMalformed, token)
+		}
+	})
+
+	t.Run("TamperedPayload", func(t *testing.T) {
+		token := signTestJWT(t, "HS256", "", secret, validClaims())
+		c := validClaims()
+		c.Subject = "admin"
+		forged := signTestJWT(t, "HS256", "", secret, c)
+		// 拼接合法的签名与篡改后的 payload
+		parts := []byte(forged[:len(forged)-43] + token[len(token)-43:])
+		_, err := validate(context.Background(), string(parts))
+		assert.ErrorIs(t, err, ErrTokenSignature)
+	})
+}
+
+func TestJWT_Strategy(t *testing.T) {
+	secret := []byte("secret")
+	strategy := JWT[testClaims](JWTOptions{Keys: StaticKeys{"k1": secret}, Cookie: "access_token", Realm: "example"})
+	handler := Auth(strategy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := GetIdentity(r.Context()).(*testClaims)
+		if claims == nil {
+			w.Write([]byte("anonymous"))
+			return
+		}
+		w.Write([]byte(claims.Subject))
+	}))
+
+	serve := func(setup func(r *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		setup(req)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+signTestJWT(t, "HS256", "k1", secret, validClaims()))
+	})
+	assert.Equal(t, "user-1", w.Body.String())
+
+	w = serve(func(r *http.Request) {
+		r.AddCookie(&http.Cookie{Name: "access_token", Value: signTestJWT(t, "HS256", "k1", secret, validClaims())})
+	})
+	assert.Equal(t, "user-1", w.Body.String())
+
+	// 未提供凭证：继续执行，由 AuthRequired 决定是否拦截
+	w = serve(func(r *http.Request) {})
+	assert.Equal(t, "anonymous", w.Body.String())
+
+	c := validClaims()
+	c.ExpiresAt = NumericDate(time.Now().Add(-time.Hour).Unix())
+	w = serve(func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+signTestJWT(t, "HS256", "k1", secret, c))
+	})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer realm="example", error="invalid_token", error_description="token is expired"`, w.Header().Get("WWW-Authenticate"))
+	assert.Contains(t, w.Body.String(), CodeUnauthorized)
+
+	w = serve(func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+signTestJWT(t, "HS256", "unknown", secret, validClaims()))
+	})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "token signing key not found")
+}
+
+func TestRegisteredClaims_IdentitySubject(t *testing.T) {
+	// 嵌入 RegisteredClaims 的 Claims 在日志中只记录 sub
+	claims := validClaims()
+	claims.Scope = "admin"
+	id, ok := identityLogValue(&claims)
+	assert.True(t, ok)
+	assert.Equal(t, "user-1", id)
+}
+
+func testJWKSDoc(t *testing.T, kid string, rsaPub *rsa.PublicKey, ecPub *ecdsa.PublicKey, edPub ed25519.PublicKey) []byte {
+	t.Helper()
+	enc := base64.RawURLEncoding
+	var keys []map[string]string
+	if rsaPub != nil {
+		keys = append(keys, map[string]string{
+			"kty": "RSA", "kid": kid, "alg": "RS256", "use": "sig",
+			"n": enc.EncodeToString(rsaPub.N.Bytes()),
+			"e": enc.EncodeToString(big.NewInt(int64(rsaPub.E)).Bytes()),
+		})
+	}
+	if ecPub != nil {
+		ecdh, err := ecPub.Bytes()
+		require.NoError(t, err)
+		size := (len(ecdh) - 1) / 2
+		keys = append(keys, map[string]string{
+			"kty": "EC", "kid": kid + "-ec", "crv": "P-256",
+			"x": enc.EncodeToString(ecdh[1 : 1+size]),
+			"y": enc.EncodeToString(ecdh[1+size:]),
+		})
+	}
+	if edPub != nil {
+		keys = append(keys, map[string]string{"kty": "OKP", "kid": kid + "-ed", "crv": "Ed25519", "x": enc.EncodeToString(edPub)})
+	}
+	// 加密用途的密钥与不支持的曲线应被忽略，而不是让整个文档失效
+	keys = append(keys,
+		map[string]string{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+		map[string]string{"kty": "EC", "kid": "k256", "crv": "secp256k1", "x": "AQAB", "y": "AQAB"},
+		map[string]string{"kty": "OKP", "kid": "x25519", "crv": "X25519", "x": "AQAB"},
+	)
+	doc, err := sonic.Marshal(map[string]any{"keys": keys})
+	require.NoError(t, err)
+	return doc
+}
+
+func TestJWKS(t *testing.T) {
+	rsa1, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsa2, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edPriv, _ := ed25519.GenerateKey(rand.Reader)
+
+	var current atomic.Value
+	current.Store(testJWKSDoc(t, "k1", &rsa1.PublicKey, &ecKey.PublicKey, edPub))
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write(current.Load().([]byte))
+	}))
+	defer srv.Close()
+
+	jwks := NewJWKS(JWKSFromURL(srv.URL, srv.Client()), JWKSOptions{MinRefreshInterval: time.Nanosecond})
+	validate := JWTValidator[testClaims](JWTOptions{Keys: jwks})
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		alg, kid string
+		key      any
+	}{{"RS256", "k1", rsa1}, {"ES256", "k1-ec", ecKey}, {"EdDSA", "k1-ed", edPriv}} {
+		_, err = validate(ctx, signTestJWT(t, tc.alg, tc.kid, tc.key, validClaims()))
+		assert.NoError(t, err, tc.alg)
+	}
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// JWK 声明了 alg=RS256，不能被用于 PS256
+	_, err = validate(ctx, signTestJWT(t, "PS256", "k1", rsa1, validClaims()))
+	assert.ErrorIs(t, err, ErrTokenKeyNotFound)
+
+	// 密钥轮换：未知 kid 触发刷新
+	current.Store(testJWKSDoc(t, "k2", &rsa2.PublicKey, nil, nil))
+	_, err = validate(ctx, signTestJWT(t, "RS256", "k2", rsa2, validClaims()))
+	assert.NoError(t, err)
+
+	// JWKS 端点故障时沿用旧密钥
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	assert.ErrorContains(t, jwks.Refresh(ctx), "unexpected status 500")
+	_, err = validate(ctx, signTestJWT(t, "RS256", "k2", rsa2, validClaims()))
+	assert.NoError(t, err)
+}
+
+func TestJWKS_FromFile(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, testJWKSDoc(t, "file", &rsaKey.PublicKey, nil, nil), 0o600))
+
+	validate := JWTValidator[testClaims](JWTOptions{Keys: NewJWKS(JWKSFromFile(path))})
+	_, err = validate(context.Background(), signTestJWT(t, "RS256", "file", rsaKey, validClaims()))
+	assert.NoError(t, err)
+
+	// 从未成功加载过时返回拉取错误 (而不是 invalid_token)
+	missing := JWTValidator[testClaims](JWTOptions{Keys: NewJWKS(JWKSFromFile(path + ".missing"))})
+	_, err = missing(context.Background(), signTestJWT(t, "RS256", "file", rsaKey, validClaims()))
+	assert.ErrorContains(t, err, "fetch JWKS")
+	assert.False(t, isTokenError(err))
+}
+
+func TestJWKS_SymmetricKeys(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	doc := []byte(`{"keys":[{"kty":"oct","kid":"hs","alg":"HS256","k":"` + base64.RawURLEncoding.EncodeToString(secret) + `"}]}`)
+	source := func(ctx context.Context) ([]byte, error) { return doc, nil }
+	token := signTestJWT(t, "HS256", "hs", secret, validClaims())
+
+	// 默认忽略 oct 密钥：能发布 JWKS 的人不应由此获得签发 Token 的能力
+	validate := JWTValidator[testClaims](JWTOptions{Keys: NewJWKS(source)})
+	_, err := validate(context.Background(), token)
+	assert.ErrorIs(t, err, ErrTokenKeyNotFound)
+
+	validate = JWTValidator[testClaims](JWTOptions{Keys: NewJWKS(source, JWKSOptions{AllowSymmetricKeys: true})})
+	_, err = validate(context.Background(), token)
+	assert.NoError(t, err)
+}
+
+func TestJWKS_Outage(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	jwks := NewJWKS(func(ctx context.Context) ([]byte, error) {
+		fetches.Add(1)
+		<-release
+		return nil, errors.New("idp down")
+	})
+
+	// 并发请求共享同一次拉取
+	var wg sync.WaitGroup
+	errs := make([]error, 20)
+	for i := range errs {
+		wg.Go(func() {
+			_, errs[i] = jwks.Key(context.Background(), "k1", "RS256")
+		})
+	}
+	require.Eventually(t, func() bool { return fetches.Load() == 1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	for _, err := range errs {
+		assert.ErrorContains(t, err, "idp down")
+	}
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// 首次加载失败后同样受 MinRefreshInterval 限制
+	_, err := jwks.Key(context.Background(), "k1", "RS256")
+	assert.ErrorContains(t, err, "idp down")
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// 等待者取消请求不影响进行中的拉取
+	release = make(chan struct{})
+	jwks = NewJWKS(func(ctx context.Context) ([]byte, error) {
+		<-release
+		return []byte(`{"keys":[]}`), ctx.Err()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = jwks.Key(ctx, "k1", "RS256")
+	assert.ErrorIs(t, err, context.Canceled)
+	close(release)
+	_, err = jwks.Key(context.Background(), "k1", "RS256")
+	assert.ErrorIs(t, err, ErrTokenKeyNotFound)
+}
@@ -0,0 +1,214 @@
+package httpx
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// ErrClientCertInvalid 客户端证书无法通过验证
+var ErrClientCertInvalid = &HttpError{HttpCode: http.StatusUnauthorized, BizCode: CodeUnauthorized, Msg: "invalid client certificate"}
+
+// MTLSOptions 配置客户端证书认证。
+type MTLSOptions struct {
+	// Roots 验证客户端证书链的 CA 池。
+	// 为 nil 时只接受 TLS 层已经验证过的证书 (tls.Config.ClientAuth 为 VerifyClientCertIfGiven
+	// 或 RequireAndVerifyClientCert)，转发证书的场景下必填。
+	Roots *x509.CertPool
+
+	// TrustedProxies 允许通过 ForwardedHeader 转发客户端证书的代理 CIDR，
+	// 与 NewClientIPMiddleware 使用相同的判断逻辑 (以 RemoteAddr 为准)。
+	TrustedProxies []string
+
+	// ForwardedHeader 代理转发客户端证书的请求头。默认 "X-Forwarded-Client-Cert"。
+	// 支持 URL 编码的 PEM (nginx $ssl_client_escaped_cert、AWS ALB) 与 Envoy XFCC 的 Cert="..." 字段；
+	// XFCC 有多个元素时只使用最右侧的元素，因此多层代理时内层代理应原样转发 (FORWARD_ONLY)。
+	ForwardedHeader string
+
+	// MapIdentity 将证书映射为业务身份 (如查询服务账号、校验 SPIFFE 信任域)。
+	// 返回 *HttpError 时请求被拒绝；默认直接使用 *ClientCertIdentity。
+	MapIdentity func(ctx context.Context, id *ClientCertIdentity) (any, error)
+}
+
+// ClientCertIdentity 是从客户端证书中提取的身份信息。
+type ClientCertIdentity struct {
+	// SPIFFEID 证书中 spiffe:// 形式的 URI SAN，如 "spiffe://example.org/ns/prod/sa/billing"。
+	SPIFFEID string
+	// CommonName 证书主题的 CN。
+	CommonName string
+	DNSNames   []string
+	URIs       []string
+	Emails     []string
+	// Certificate 叶子证书。
+	Certificate *x509.Certificate
+}
+
+// IdentitySubject 实现 SubjectHolder，日志中只记录 SPIFFE ID (没有时为 CN)，不记录证书本身。
+func (id *ClientCertIdentity) IdentitySubject() string {
+	if id.SPIFFEID != "" {
+		return id.SPIFFEID
+	}
+	return id.CommonName
+}
+
+// TrustDomain 返回 SPIFFE ID 的信任域，如 "example.org"。
+func (id *ClientCertIdentity) TrustDomain() string {
+	rest, ok := strings.CutPrefix(id.SPIFFEID, "spiffe://")
+	if !ok {
+		return ""
+	}
+	domain, _, _ := strings.Cut(rest, "/")
+	return domain
+}
+
+// MTLSAuth 创建一个客户端证书认证策略。
+// 证书来自 r.TLS.PeerCertificates，或在直连对端是可信代理时来自 ForwardedHeader；
+// 验证链 (要求 ExtKeyUsageClientAuth) 后，将 SAN 映射为身份。
+// 未出示证书时返回 ErrNoCredentials，可与其他策略通过 AuthChain 组合。
+func MTLSAuth(opts MTLSOptions) AuthStrategy {
+	proxies := parseTrustedProxies(opts.TrustedProxies)
+	if len(proxies) > 0 && opts.Roots == nil {
+		panic("httpx: MTLSOptions.Roots is required when certificates are forwarded by proxies")
+	}
+	if opts.ForwardedHeader == "" {
+		opts.ForwardedHeader = "X-Forwarded-Client-Cert"
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) (any, error) {
+		var chain []*x509.Certificate
+		verified := false
+		if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+			chain = r.TLS.PeerCertificates
+			verified = len(r.TLS.VerifiedChains) > 0
+		} else if v := r.Header.Get(opts.ForwardedHeader); v != "" && proxies.contains(remoteHost(r)) {
+			var err error
+			if chain, err = parseForwardedCert(v); err != nil {
+				return nil, ErrClientCertInvalid
+			}
+		}
+		if len(chain) == 0 {
+			return nil, ErrNoCredentials
+		}
+
+		leaf := chain[0]
+		if opts.Roots != nil {
+			intermediates := x509.NewCertPool()
+			for _, c := range chain[1:] {
+				intermediates.AddCert(c)
+			}
+			if _, err := leaf.Verify(x509.VerifyOptions{
+				Roots:         opts.Roots,
+				Intermediates: intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			}); err != nil {
+				return nil, ErrClientCertInvalid
+			}
+		} else if !verified {
+			// TLS 层只请求了证书却没有验证 (RequestClientCert / RequireAnyClientCert)
+			return nil, ErrClientCertInvalid
+		}
+
+		id := newClientCertIdentity(leaf)
+		if opts.MapIdentity == nil {
+			return id, nil
+		}
+		return opts.MapIdentity(r.Context(), id)
+	}
+}
+
+func newClientCertIdentity(cert *x509.Certificate) *ClientCertIdentity {
+	id := &ClientCertIdentity{
+		CommonName:  cert.Subject.CommonName,
+		DNSNames:    cert.DNSNames,
+		Emails:      cert.EmailAddresses,
+		Certificate: cert,
+	}
+	for _, u := range cert.URIs {
+		id.URIs = append(id.URIs, u.String())
+		if u.Scheme == "spiffe" && id.SPIFFEID == "" {
+			id.SPIFFEID = u.String()
+		}
+	}
+	return id
+}
+
+// parseForwardedCert 解析代理转发的证书链 (叶子证书在前)。
+// Envoy XFCC 中没有 Cert 字段时 (如只转发了 Hash/URI) 返回空链，调用方视为未出示证书。
+func parseForwardedCert(v string) ([]*x509.Certificate, error) {
+	// Envoy XFCC: By=...;Hash=...;Cert="<url-encoded PEM>";URI=...
+	// 多跳时每个代理以逗号追加一个元素。左侧的元素可能由客户端伪造 (边缘代理配置为 APPEND_FORWARD 时)，
+	// 因此只使用最右侧、由直连的可信代理追加的元素。
+	if isXFCC(v) {
+		elements := splitXFCC(v, ',')
+		var cert string
+		for _, kv := range splitXFCC(elements[len(elements)-1], ';') {
+			if val, ok := strings.CutPrefix(strings.TrimSpace(kv), "Cert="); ok {
+				cert = strings.Trim(val, `"`)
+				break
+			}
+		}
+		if cert == "" {
+			return nil, nil
+		}
+		v = cert
+	}
+
+	// 使用 PathUnescape，避免把 PEM 中未编码的 '+' 当作空格
+	decoded, err := url.PathUnescape(v)
+	if err != nil {
+		return nil, err
+	}
+	var chain []*x509.Certificate
+	rest := []byte(decoded)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("httpx: no certificate in forwarded header")
+	}
+	return chain, nil
+}
+
+// splitXFCC 按 sep 切分 XFCC，忽略双引号内的分隔符 (如 Subject="CN=a,O=b")。
+func splitXFCC(v string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, v[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, v[start:])
+}
+
+// xfccKeys 是 Envoy XFCC 元素中的字段名
+var xfccKeys = []string{"By", "Hash", "Cert", "Chain", "Subject", "URI", "DNS"}
+
+// isXFCC 判断请求头是否为 Envoy XFCC 格式 (以 XFCC 字段开头)，而不是 URL 编码的 PEM。
+func isXFCC(v string) bool {
+	key, _, ok := strings.Cut(strings.TrimSpace(v), "=")
+	return ok && slices.Contains(xfccKeys, key)
+}
@@ -0,0 +1,191 @@
+package httpx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage, uris ...string) *x509.Certificate {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     []string{cn + ".internal"},
+	}
+	for _, u := range uris {
+		parsed, err := url.Parse(u)
+		require.NoError(t, err)
+		tmpl.URIs = append(tmpl.URIs, parsed)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	cert, _ := x509.ParseCertificate(der)
+	return cert
+}
+
+func escapedPEM(cert *x509.Certificate) string {
+	return url.PathEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+}
+
+func TestMTLSAuth(t *testing.T) {
+	ca := newTestCA(t)
+	client := ca.issue(t, "billing", x509.ExtKeyUsageClientAuth, "spiffe://example.org/ns/prod/sa/billing")
+	server := ca.issue(t, "web", x509.ExtKeyUsageServerAuth)
+	rogue := newTestCA(t).issue(t, "rogue", x509.ExtKeyUsageClientAuth)
+
+	strategy := MTLSAuth(MTLSOptions{Roots: ca.pool, TrustedProxies: []string{"10.0.0.0/8"}})
+
+	withTLS := func(certs ...*x509.Certificate) *http.Request {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.TLS = &tls.ConnectionState{PeerCertificates: certs}
+		return req
+	}
+
+	t.Run("PeerCertificate", func(t *testing.T) {
+		identity, err := strategy(nil, withTLS(client))
+		require.NoError(t, err)
+		id := identity.(*ClientCertIdentity)
+		assert.Equal(t, "spiffe://example.org/ns/prod/sa/billing", id.SPIFFEID)
+		assert.Equal(t, "example.org", id.TrustDomain())
+		assert.Equal(t, id.SPIFFEID, id.IdentitySubject())
+		assert.Equal(t, "billing", id.CommonName)
+		assert.Equal(t, []string{"billing.internal"}, id.DNSNames)
+	})
+
+	t.Run("NoCertificate", func(t *testing.T) {
+		_, err := strategy(nil, httptest.NewRequest("GET", "/", nil))
+		assert.ErrorIs(t, err, ErrNoCredentials)
+		_, err = strategy(nil, withTLS())
+		assert.ErrorIs(t, err, ErrNoCredentials)
+	})
+
+	t.Run("UntrustedCA", func(t *testing.T) {
+		_, err := strategy(nil, withTLS(rogue))
+		assert.ErrorIs(t, err, ErrClientCertInvalid)
+	})
+
+	t.Run("ServerCertificate", func(t *testing.T) {
+		_, err := strategy(nil, withTLS(server))
+		assert.ErrorIs(t, err, ErrClientCertInvalid)
+	})
+
+	t.Run("ForwardedFromTrustedProxy", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.1.2.3:4567"
+		req.Header.Set("X-Forwarded-Client-Cert", escapedPEM(client))
+		identity, err := strategy(nil, req)
+		require.NoError(t, err)
+		assert.Equal(t, "billing", identity.(*ClientCertIdentity).CommonName)
+
+		// Envoy XFCC 格式
+		req.Header.Set("X-Forwarded-Client-Cert", `By=spiffe://example.org/web;Hash=abc;Cert="`+escapedPEM(client)+`";URI=spiffe://example.org/ns/prod/sa/billing`)
+		identity, err = strategy(nil, req)
+		require.NoError(t, err)
+		assert.Equal(t, "spiffe://example.org/ns/prod/sa/billing", identity.(*ClientCertIdentity).SPIFFEID)
+
+		// 多个元素时只使用最右侧、由可信代理追加的元素，客户端伪造的左侧元素被忽略
+		req.Header.Set("X-Forwarded-Client-Cert", `Cert="`+escapedPEM(rogue)+`",By=spiffe://example.org/web;Cert="`+escapedPEM(client)+`";Subject="CN=billing,O=acme"`)
+		identity, err = strategy(nil, req)
+		require.NoError(t, err)
+		assert.Equal(t, "billing", identity.(*ClientCertIdentity).CommonName)
+
+		req.Header.Set("X-Forwarded-Client-Cert", `Cert="`+escapedPEM(client)+`",Cert="`+escapedPEM(rogue)+`"`)
+		_, err = strategy(nil, req)
+		assert.ErrorIs(t, err, ErrClientCertInvalid)
+
+		// XFCC 没有 Cert 字段时视为未出示证书
+		req.Header.Set("X-Forwarded-Client-Cert", `By=spiffe://example.org/web;Hash=abc;URI=spiffe://example.org/ns/prod/sa/billing`)
+		_, err = strategy(nil, req)
+		assert.ErrorIs(t, err, ErrNoCredentials)
+
+		// 转发的证书同样需要通过链验证
+		req.Header.Set("X-Forwarded-Client-Cert", escapedPEM(rogue))
+		_, err = strategy(nil, req)
+		assert.ErrorIs(t, err, ErrClientCertInvalid)
+	})
+
+	t.Run("ForwardedFromUntrustedPeer", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "203.0.113.9:4567"
+		req.Header.Set("X-Forwarded-Client-Cert", escapedPEM(client))
+		_, err := strategy(nil, req)
+		assert.ErrorIs(t, err, ErrNoCredentials)
+	})
+
+	t.Run("VerifiedByTLSLayer", func(t *testing.T) {
+		tlsOnly := MTLSAuth(MTLSOptions{})
+		req := withTLS(client)
+		_, err := tlsOnly(nil, req)
+		assert.ErrorIs(t, err, ErrClientCertInvalid, "unverified peer certificates must be rejected")
+
+		req.TLS.VerifiedChains = [][]*x509.Certificate{{client, ca.cert}}
+		_, err = tlsOnly(nil, req)
+		assert.NoError(t, err)
+	})
+
+	t.Run("MapIdentity", func(t *testing.T) {
+		mapped := MTLSAuth(MTLSOptions{Roots: ca.pool, MapIdentity: func(ctx context.Context, id *ClientCertIdentity) (any, error) {
+			if id.TrustDomain() != "example.org" {
+				return nil, ErrForbidden
+			}
+			return "svc:" + id.CommonName, nil
+		}})
+		handler := Auth(mapped)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(GetIdentity(r.Context()).(string)))
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, withTLS(client))
+		assert.Equal(t, "svc:billing", w.Body.String())
+
+		other := ca.issue(t, "other", x509.ExtKeyUsageClientAuth, "spiffe://evil.org/sa/x")
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, withTLS(other))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 // mockValidator 创建一个简单的验证器闭包
@@ -215,3 +217,39 @@ func TestFromCookie_InternalLogic(t *testing.T) {
 		}
 	})
 }
+
+func TestAuthContextAccessors(t *testing.T) {
+	ctx := context.Background()
+
+	// 未经过 Auth 中间件时不应 panic
+	assert.NoError(t, AuthErrorFrom(ctx))
+	assert.NoError(t, GetAuthError(ctx))
+	_, ok := IdentityAs[string](ctx)
+	assert.False(t, ok)
+
+	failure := errors.New("token revoked")
+	handler := Auth(func(w http.ResponseWriter, r *http.Request) (any, error) {
+		if r.Header.Get("X-Fail") != "" {
+			return nil, failure
+		}
+		return &APIKey{Owner: "partner-1"}, nil
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, ok := IdentityAs[*APIKey](r.Context()); ok {
+			w.Write([]byte(key.Owner))
+			return
+		}
+		_, ok := IdentityAs[string](r.Context())
+		assert.False(t, ok)
+		w.Write([]byte(AuthErrorFrom(r.Context()).Error()))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "partner-1", w.Body.String())
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Fail", "1")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, "token revoked", w.Body.String())
+}
@@ -0,0 +1,148 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// 授权失败的业务码 (403)
+const (
+	// CodeInsufficientScope 缺少所需的权限范围 (Scope)
+	CodeInsufficientScope = "INSUFFICIENT_SCOPE"
+	// CodeMissingRole 缺少所需的角色
+	CodeMissingRole = "MISSING_ROLE"
+	// CodeMissingPermission 缺少所需的权限
+	CodeMissingPermission = "MISSING_PERMISSION"
+)
+
+// 身份可以实现以下接口，以便被 HasScope/HasRole/HasPermission 策略检查。
+// 例如 *APIKey 实现了 ScopeHolder；JWT Claims 结构体可以根据自身的 scope/roles 字段实现它们。
+type (
+	ScopeHolder interface {
+		HasScope(scope string) bool
+	}
+	RoleHolder interface {
+		HasRole(role string) bool
+	}
+	PermissionHolder interface {
+		HasPermission(permission string) bool
+	}
+)
+
+// Policy 是一条授权规则，返回 nil 表示允许。
+// identity 来自 GetIdentity (未认证时为 nil)；req 是绑定并验证后的请求结构体指针，
+// 在 Require 中间件中 (尚未绑定) 为 nil。
+// 拒绝时应返回 *HttpError (通常为 403)，它会原样交给 ErrorFunc 渲染。
+type Policy func(ctx context.Context, identity any, req any) error
+
+// HasScope 要求身份实现 ScopeHolder 且拥有全部 scopes。
+func HasScope(scopes ...string) Policy {
+	return func(ctx context.Context, identity any, req any) error {
+		if identity == nil {
+			return ErrUnauthorized
+		}
+		h, _ := identity.(ScopeHolder)
+		for _, s := range scopes {
+			if h == nil || !h.HasScope(s) {
+				return &HttpError{HttpCode: http.StatusForbidden, BizCode: CodeInsufficientScope, Msg: fmt.Sprintf("missing scope %q", s)}
+			}
+		}
+		return nil
+	}
+}
+
+// HasRole 要求身份实现 RoleHolder 且拥有全部 roles。
+func HasRole(roles ...string) Policy {
+	return func(ctx context.Context, identity any, req any) error {
+		if identity == nil {
+			return ErrUnauthorized
+		}
+		h, _ := identity.(RoleHolder)
+		for _, role := range roles {
+			if h == nil || !h.HasRole(role) {
+				return &HttpError{HttpCode: http.StatusForbidden, BizCode: CodeMissingRole, Msg: fmt.Sprintf("missing role %q", role)}
+			}
+		}
+		return nil
+	}
+}
+
+// HasPermission 要求身份实现 PermissionHolder 且拥有全部 permissions。
+func HasPermission(permissions ...string) Policy {
+	return func(ctx context.Context, identity any, req any) error {
+		if identity == nil {
+			return ErrUnauthorized
+		}
+		h, _ := identity.(PermissionHolder)
+		for _, p := range permissions {
+			if h == nil || !h.HasPermission(p) {
+				return &HttpError{HttpCode: http.StatusForbidden, BizCode: CodeMissingPermission, Msg: fmt.Sprintf("missing permission %q", p)}
+			}
+		}
+		return nil
+	}
+}
+
+// AllOf 要求全部策略通过，返回第一个拒绝原因。
+func AllOf(policies ...Policy) Policy {
+	return func(ctx context.Context, identity any, req any) error {
+		for _, p := range policies {
+			if err := p(ctx, identity, req); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// AnyOf 要求至少一个策略通过。全部拒绝时返回第一个拒绝原因。
+func AnyOf(policies ...Policy) Policy {
+	return func(ctx context.Context, identity any, req any) error {
+		var first error
+		for _, p := range policies {
+			err := p(ctx, identity, req)
+			if err == nil {
+				return nil
+			}
+			if first == nil {
+				first = err
+			}
+		}
+		if first == nil {
+			first = ErrForbidden
+		}
+		return first
+	}
+}
+
+// ResourcePolicy 将针对具体请求类型的检查 (如 "只能修改自己的订单") 适配为 Policy。
+// 只能通过 WithPolicy 用于 Req 类型匹配的 Handler；在 Require 中间件中或类型不匹配时返回 500，
+// 以免配置错误被静默地当作拒绝或放行。
+func ResourcePolicy[Req any](fn func(ctx context.Context, identity any, req *Req) error) Policy {
+	return func(ctx context.Context, identity any, req any) error {
+		r, ok := req.(*Req)
+		if !ok {
+			return fmt.Errorf("httpx: ResourcePolicy[%T] applied to %T", (*Req)(nil), req)
+		}
+		return fn(ctx, identity, r)
+	}
+}
+
+// Require 返回一个授权中间件，在调用下游之前对 GetIdentity 的结果执行 policy。
+// 应放在 Auth 中间件之后。未认证时返回 401，拒绝时返回 403 (由 policy 决定业务码)。
+func Require(policy Policy, Errors ...ErrorFunc) Middleware {
+	errorFunc := Error
+	if len(Errors) > 0 {
+		errorFunc = Errors[0]
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := policy(r.Context(), GetIdentity(r.Context()), nil); err != nil {
+				errorFunc(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
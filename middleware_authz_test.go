@@ -0,0 +1,138 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPrincipal struct {
+	ID          string
+	Roles       []string
+	Permissions []string
+}
+
+func (p *testPrincipal) HasRole(role string) bool { return slices.Contains(p.Roles, role) }
+
+func (p *testPrincipal) HasPermission(perm string) bool { return slices.Contains(p.Permissions, perm) }
+
+func withIdentity(r *http.Request, identity any) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), IdentityKey{}, identity))
+}
+
+func TestPolicies(t *testing.T) {
+	ctx := context.Background()
+	admin := &testPrincipal{ID: "u1", Roles: []string{"admin"}, Permissions: []string{"orders:delete"}}
+	key := &APIKey{Scopes: []string{"orders:read"}}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		identity any
+		wantCode string
+	}{
+		{name: "RoleOK", policy: HasRole("admin"), identity: admin},
+		{name: "RoleMissing", policy: HasRole("admin", "auditor"), identity: admin, wantCode: CodeMissingRole},
+		{name: "ScopeOK", policy: HasScope("orders:read"), identity: key},
+		{name: "ScopeMissing", policy: HasScope("orders:write"), identity: key, wantCode: CodeInsufficientScope},
+		{name: "ScopeNotSupported", policy: HasScope("orders:read"), identity: admin, wantCode: CodeInsufficientScope},
+		{name: "PermissionOK", policy: HasPermission("orders:delete"), identity: admin},
+		{name: "Anonymous", policy: HasRole("admin"), wantCode: CodeUnauthorized},
+		{name: "AllOf", policy: AllOf(HasRole("admin"), HasPermission("orders:refund")), identity: admin, wantCode: CodeMissingPermission},
+		{name: "AnyOf", policy: AnyOf(HasScope("orders:write"), HasRole("admin")), identity: admin},
+		{name: "AnyOfDenied", policy: AnyOf(HasRole("auditor"), HasPermission("x")), identity: admin, wantCode: CodeMissingRole},
+		{name: "Nested", policy: AnyOf(AllOf(HasRole("admin"), HasPermission("orders:delete")), HasScope("root")), identity: admin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy(ctx, tt.identity, nil)
+			if tt.wantCode == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var httpErr *HttpError
+			require.ErrorAs(t, err, &httpErr)
+			assert.Equal(t, tt.wantCode, httpErr.BizCode)
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	handler := Require(HasRole("admin"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		name     string
+		identity any
+		wantCode int
+		wantBiz  string
+	}{
+		{name: "Allowed", identity: &testPrincipal{Roles: []string{"admin"}}, wantCode: http.StatusOK},
+		{name: "Forbidden", identity: &testPrincipal{}, wantCode: http.StatusForbidden, wantBiz: CodeMissingRole},
+		{name: "Anonymous", wantCode: http.StatusUnauthorized, wantBiz: CodeUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.identity != nil {
+				req = withIdentity(req, tt.identity)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantBiz != "" {
+				var resp Response[any]
+				require.NoError(t, sonic.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantBiz, resp.Code)
+			}
+		})
+	}
+}
+
+type updateOrderReq struct {
+	OwnerID string `json:"owner_id"`
+}
+
+func TestWithPolicy_Resource(t *testing.T) {
+	ownOrder := ResourcePolicy(func(ctx context.Context, identity any, req *updateOrderReq) error {
+		if identity.(*testPrincipal).ID != req.OwnerID {
+			return ErrForbidden
+		}
+		return nil
+	})
+	called := false
+	h := NewHandler(func(ctx context.Context, req *updateOrderReq) (*TestRes, error) {
+		called = true
+		return &TestRes{ID: req.OwnerID}, nil
+	}, WithPolicy(HasPermission("orders:write")), WithPolicy(AnyOf(HasRole("admin"), ownOrder)))
+
+	serve := func(identity *testPrincipal, body string) int {
+		called = false
+		req := httptest.NewRequest("PUT", "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, withIdentity(req, identity))
+		return w.Code
+	}
+
+	writer := []string{"orders:write"}
+	assert.Equal(t, http.StatusOK, serve(&testPrincipal{ID: "u1", Permissions: writer}, `{"owner_id":"u1"}`))
+	assert.True(t, called)
+	assert.Equal(t, http.StatusForbidden, serve(&testPrincipal{ID: "u2", Permissions: writer}, `{"owner_id":"u1"}`))
+	assert.False(t, called)
+	assert.Equal(t, http.StatusOK, serve(&testPrincipal{ID: "u2", Roles: []string{"admin"}, Permissions: writer}, `{"owner_id":"u1"}`))
+	assert.Equal(t, http.StatusForbidden, serve(&testPrincipal{ID: "u1"}, `{"owner_id":"u1"}`))
+
+	// ResourcePolicy 用在中间件中属于配置错误，返回 500 而不是静默放行
+	w := httptest.NewRecorder()
+	Require(ownOrder)(http.NotFoundHandler()).ServeHTTP(w, withIdentity(httptest.NewRequest("GET", "/", nil), &testPrincipal{}))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
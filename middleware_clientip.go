@@ -16,35 +16,17 @@ type clientIPKey struct{}
 // OR you can decide to trust all if you explicitly pass specific value (not implemented here for safety).
 // NOTE: To trust all (e.g. dev mode), pass "0.0.0.0/0".
 func NewClientIPMiddleware(trustedProxiesCIDR []string) func(http.Handler) http.Handler {
-	var trustedProxies []*net.IPNet
-	for _, cidr := range trustedProxiesCIDR {
-		_, ipNet, err := net.ParseCIDR(cidr)
-		if err == nil {
-			trustedProxies = append(trustedProxies, ipNet)
-		}
-	}
+	trusted := parseTrustedProxies(trustedProxiesCIDR)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			var ip string
 
 			// Get immediate peer IP (RemoteAddr)
-			remoteIPStr, _, err := net.SplitHostPort(r.RemoteAddr)
-			if err != nil {
-				remoteIPStr = r.RemoteAddr
-			}
-			remoteIP := net.ParseIP(remoteIPStr)
+			remoteIPStr := remoteHost(r)
 
 			// Check if the immediate peer is a trusted proxy
-			isTrusted := false
-			if remoteIP != nil {
-				for _, proxyNet := range trustedProxies {
-					if proxyNet.Contains(remoteIP) {
-						isTrusted = true
-						break
-					}
-				}
-			}
+			isTrusted := trusted.contains(remoteIPStr)
 
 			if isTrusted {
 				// 1. Check X-Forwarded-For
@@ -77,6 +59,7 @@ func NewClientIPMiddleware(trustedProxiesCIDR []string) func(http.Handler) http.
 			ip = strings.TrimSuffix(ip, "]")
 
 			ctx := context.WithValue(r.Context(), clientIPKey{}, ip)
+			recordClientIP(ctx, ip)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -90,3 +73,41 @@ func ClientIP(ctx context.Context) string {
 	}
 	return ""
 }
+
+// trustedProxies is a set of proxy networks whose forwarding headers are trusted.
+type trustedProxies []*net.IPNet
+
+// parseTrustedProxies parses CIDR strings, silently skipping invalid entries.
+func parseTrustedProxies(cidrs []string) trustedProxies {
+	var nets trustedProxies
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err == nil {
+			nets = append(nets, ipNet)
+		}
+	}
+	return nets
+}
+
+// contains reports whether ip belongs to one of the trusted proxy networks.
+func (t trustedProxies) contains(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, proxyNet := range t {
+		if proxyNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteHost returns the host part of r.RemoteAddr (the immediate peer).
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
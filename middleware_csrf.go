@@ -0,0 +1,232 @@
+package httpx
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// CodeCSRF CSRF 校验失败的业务码 (403)
+const CodeCSRF = "CSRF_FAILED"
+
+// CSRF 校验失败的错误
+var (
+	ErrCSRFOrigin       = &HttpError{HttpCode: http.StatusForbidden, BizCode: CodeCSRF, Msg: "cross-origin request rejected"}
+	ErrCSRFTokenMissing = &HttpError{HttpCode: http.StatusForbidden, BizCode: CodeCSRF, Msg: "CSRF token missing"}
+	ErrCSRFTokenInvalid = &HttpError{HttpCode: http.StatusForbidden, BizCode: CodeCSRF, Msg: "CSRF token invalid"}
+)
+
+// csrfTokenSize 令牌的随机字节数
+const csrfTokenSize = 32
+
+// CSRFStore 为同步令牌模式在服务端保存与会话绑定的令牌。
+// GetCSRFToken 在会话中没有令牌时返回 ("", nil)。
+type CSRFStore interface {
+	GetCSRFToken(r *http.Request) (string, error)
+	SaveCSRFToken(w http.ResponseWriter, r *http.Request, token string) error
+}
+
+// CSRFOptions 配置 CSRF 防护。
+type CSRFOptions struct {
+	// Store 非 nil 时使用同步令牌 (Synchronizer Token) 模式，令牌保存在服务端会话中；
+	// 为 nil 时使用双重提交 Cookie (Double-Submit Cookie) 模式，令牌通过 SetCookie 下发，
+	// 默认带有 __Host- 前缀，子域无法覆盖。
+	Store CSRFStore
+
+	// CookieName 双重提交模式的 Cookie 名称。默认 "csrf_token"。
+	CookieName string
+
+	// CookieOptions 双重提交 Cookie 的额外选项。
+	// Cookie 默认是 HttpOnly 的，前端需要从 document.cookie 读取时可加上 WithExposed()；
+	// 本地 HTTP 调试时可加上 WithInsecure() (此时不再有 __Host- 前缀)。
+	CookieOptions []CookieOption
+
+	// HeaderName 提交令牌的请求头。默认 "X-CSRF-Token"。
+	HeaderName string
+
+	// FormField 请求头缺失时，从表单的该字段读取令牌。默认 "csrf_token"。
+	FormField string
+
+	// MaxFormSize 从表单读取令牌时允许的最大请求体。默认 64KB。
+	// 读取表单字段需要解析整个请求体，而此时处理器的请求体限制尚未生效，
+	// 因此更大的表单 (如文件上传) 需要通过请求头提交令牌。
+	MaxFormSize int64
+
+	// TrustedOrigins 允许发起跨站请求的来源，形如 "https://app.example.com"。
+	TrustedOrigins []string
+
+	// OriginOnly 只做 Fetch Metadata (Sec-Fetch-Site) / Origin 校验，不要求令牌。
+	// 所有现代浏览器都会发送这些请求头，适用于不需要兼容旧浏览器的应用。
+	OriginOnly bool
+}
+
+type csrfTokenKey struct{}
+
+// CSRFToken 返回 CSRF 中间件为当前请求准备的令牌，用于渲染到表单或页面中。
+// 每次调用都返回不同的掩码形式 (防御 BREACH)，它们都能通过校验。
+// 未经过 CSRF 中间件时返回空字符串。
+func CSRFToken(ctx context.Context) string {
+	raw, _ := ctx.Value(csrfTokenKey{}).([]byte)
+	if raw == nil {
+		return ""
+	}
+	return maskCSRFToken(raw)
+}
+
+// CSRF 创建一个 CSRF 防护中间件，应放在使用 Cookie 认证 (FromCookie) 的路由上：
+//  1. 安全方法 (GET/HEAD/OPTIONS) 直接放行，但会确保令牌已经下发；
+//  2. 对其他方法，拒绝 Sec-Fetch-Site 或 Origin 表明来自其他站点 (包括同站的兄弟子域) 的请求；
+//  3. 除非 OriginOnly，还要求请求头或表单中携带与 Cookie/会话中一致的令牌。
+//
+// 校验失败时通过 ErrorFunc 返回 403。
+func CSRF(opts CSRFOptions, Errors ...ErrorFunc) Middleware {
+	errorFunc := Error
+	if len(Errors) > 0 {
+		errorFunc = Errors[0]
+	}
+	if opts.CookieName == "" {
+		opts.CookieName = "csrf_token"
+	}
+	if opts.HeaderName == "" {
+		opts.HeaderName = "X-CSRF-Token"
+	}
+	if opts.FormField == "" {
+		opts.FormField = "csrf_token"
+	}
+	if opts.MaxFormSize <= 0 {
+		opts.MaxFormSize = 64 << 10
+	}
+	header := http.CanonicalHeaderKey(opts.HeaderName)
+
+	cop := http.NewCrossOriginProtection()
+	for _, origin := range opts.TrustedOrigins {
+		if err := cop.AddTrustedOrigin(origin); err != nil {
+			panic("httpx: invalid CSRF trusted origin: " + err.Error())
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.OriginOnly {
+				if err := cop.Check(r); err != nil {
+					errorFunc(w, r, ErrCSRFOrigin)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// 1. 取得 (必要时签发) 令牌，安全方法的响应也要下发，供后续提交使用
+			expected, err := csrfExpected(w, r, &opts)
+			if err != nil {
+				errorFunc(w, r, err)
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), csrfTokenKey{}, expected))
+
+			if isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// 2. Fetch Metadata / Origin
+			if err := cop.Check(r); err != nil {
+				errorFunc(w, r, ErrCSRFOrigin)
+				return
+			}
+
+			// 3. 令牌比对
+			var submitted string
+			if v := r.Header[header]; len(v) > 0 {
+				submitted = v[0]
+			}
+			if submitted == "" && isFormRequest(r) && r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, opts.MaxFormSize)
+				submitted = r.PostFormValue(opts.FormField)
+			}
+			if submitted == "" {
+				errorFunc(w, r, ErrCSRFTokenMissing)
+				return
+			}
+			if got := unmaskCSRFToken(submitted); got == nil || subtle.ConstantTimeCompare(got, expected) != 1 {
+				errorFunc(w, r, ErrCSRFTokenInvalid)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// csrfExpected 读取当前的令牌；不存在或格式错误时生成新令牌并保存
+func csrfExpected(w http.ResponseWriter, r *http.Request, opts *CSRFOptions) ([]byte, error) {
+	var current string
+	if opts.Store != nil {
+		var err error
+		if current, err = opts.Store.GetCSRFToken(r); err != nil {
+			return nil, err
+		}
+	} else {
+		current, _ = GetCookie(r, opts.CookieName)
+	}
+	if raw, err := base64.RawURLEncoding.DecodeString(current); err == nil && len(raw) == csrfTokenSize {
+		return raw, nil
+	}
+
+	raw := make([]byte, csrfTokenSize)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	if opts.Store != nil {
+		if err := opts.Store.SaveCSRFToken(w, r, token); err != nil {
+			return nil, err
+		}
+	} else {
+		SetCookie(w, opts.CookieName, token, opts.CookieOptions...)
+	}
+	return raw, nil
+}
+
+// maskCSRFToken 返回 base64(otp || otp^token)。每次响应的令牌都不同，
+// 使压缩侧信道 (BREACH) 无法逐字节猜出令牌
+func maskCSRFToken(raw []byte) string {
+	buf := make([]byte, 2*len(raw))
+	otp := buf[:len(raw)]
+	_, _ = rand.Read(otp)
+	for i, b := range raw {
+		buf[len(raw)+i] = otp[i] ^ b
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// unmaskCSRFToken 还原提交的令牌，同时接受掩码形式与原始形式 (如前端直接读取的 Cookie 值)
+func unmaskCSRFToken(token string) []byte {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil
+	}
+	switch len(buf) {
+	case csrfTokenSize:
+		return buf
+	case 2 * csrfTokenSize:
+		raw := buf[csrfTokenSize:]
+		for i := range raw {
+			raw[i] ^= buf[i]
+		}
+		return raw
+	}
+	return nil
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func isFormRequest(r *http.Request) bool {
+	ct := r.Header.Get("Content-Type")
+	return strings.HasPrefix(ct, "application/x-www-form-urlencoded") || strings.HasPrefix(ct, "multipart/form-data")
+}
@@ -0,0 +1,180 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func csrfHandler(opts CSRFOptions) http.Handler {
+	return CSRF(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(CSRFToken(r.Context())))
+	}))
+}
+
+func TestCSRF_DoubleSubmit(t *testing.T) {
+	h := csrfHandler(CSRFOptions{})
+
+	// 安全方法：签发 __Host- Cookie，并在 Context 中提供掩码令牌
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "https://example.com/form", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	cookie := cookies[0]
+	assert.Equal(t, "__Host-csrf_token", cookie.Name)
+	masked := w.Body.String()
+	assert.NotEqual(t, cookie.Value, masked)
+
+	post := func(mutate func(r *http.Request)) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "https://example.com/transfer", nil)
+		r.AddCookie(cookie)
+		r.Header.Set("Sec-Fetch-Site", "same-origin")
+		mutate(r)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	tests := []struct {
+		name     string
+		mutate   func(r *http.Request)
+		wantCode int
+		wantMsg  string
+	}{
+		{name: "MaskedHeader", mutate: func(r *http.Request) { r.Header.Set("X-CSRF-Token", masked) }, wantCode: http.StatusOK},
+		{name: "RawHeader", mutate: func(r *http.Request) { r.Header.Set("X-CSRF-Token", cookie.Value) }, wantCode: http.StatusOK},
+		{name: "Form", mutate: func(r *http.Request) {
+			r.Body = io.NopCloser(strings.NewReader(url.Values{"csrf_token": {masked}}.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}, wantCode: http.StatusOK},
+		{name: "Multipart", mutate: func(r *http.Request) {
+			var buf bytes.Buffer
+			mw := multipart.NewWriter(&buf)
+			mw.WriteField("csrf_token", masked)
+			mw.Close()
+			r.Body = io.NopCloser(&buf)
+			r.Header.Set("Content-Type", mw.FormDataContentType())
+		}, wantCode: http.StatusOK},
+		{name: "FormTooLarge", mutate: func(r *http.Request) {
+			// 超过 MaxFormSize 的表单不会被完整读取，令牌需要通过请求头提交
+			form := url.Values{"csrf_token": {masked}, "data": {strings.Repeat("x", 64<<10)}}
+			r.Body = io.NopCloser(strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}, wantCode: http.StatusForbidden, wantMsg: "CSRF token missing"},
+		{name: "Missing", mutate: func(r *http.Request) {}, wantCode: http.StatusForbidden, wantMsg: "CSRF token missing"},
+		{name: "Wrong", mutate: func(r *http.Request) { r.Header.Set("X-CSRF-Token", maskCSRFToken(make([]byte, csrfTokenSize))) }, wantCode: http.StatusForbidden, wantMsg: "CSRF token invalid"},
+		{name: "Garbage", mutate: func(r *http.Request) { r.Header.Set("X-CSRF-Token", "!!") }, wantCode: http.StatusForbidden, wantMsg: "CSRF token invalid"},
+		{name: "CrossSite", mutate: func(r *http.Request) {
+			r.Header.Set("X-CSRF-Token", masked)
+			r.Header.Set("Sec-Fetch-Site", "cross-site")
+		}, wantCode: http.StatusForbidden, wantMsg: "cross-origin request rejected"},
+		{name: "SiblingSubdomain", mutate: func(r *http.Request) {
+			r.Header.Set("X-CSRF-Token", masked)
+			r.Header.Set("Sec-Fetch-Site", "same-site")
+		}, wantCode: http.StatusForbidden, wantMsg: "cross-origin request rejected"},
+		{name: "ForeignOrigin", mutate: func(r *http.Request) {
+			r.Header.Set("X-CSRF-Token", masked)
+			r.Header.Del("Sec-Fetch-Site")
+			r.Header.Set("Origin", "https://evil.example")
+		}, wantCode: http.StatusForbidden, wantMsg: "cross-origin request rejected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post(tt.mutate)
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantMsg != "" {
+				var resp Response[any]
+				require.NoError(t, sonic.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, CodeCSRF, resp.Code)
+				assert.Equal(t, tt.wantMsg, resp.Message)
+			}
+		})
+	}
+
+	// 没有 Cookie 的 POST 被拒绝，但响应中会签发新令牌
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "https://example.com/transfer", nil)
+	r.Header.Set("X-CSRF-Token", masked)
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Len(t, w.Result().Cookies(), 1)
+}
+
+func TestCSRF_TrustedOrigin(t *testing.T) {
+	h := csrfHandler(CSRFOptions{OriginOnly: true, TrustedOrigins: []string{"https://app.example.com"}})
+	serve := func(site, origin string) int {
+		r := httptest.NewRequest("POST", "https://api.example.com/", nil)
+		r.Header.Set("Sec-Fetch-Site", site)
+		r.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, serve("same-site", "https://app.example.com"))
+	assert.Equal(t, http.StatusForbidden, serve("same-site", "https://blog.example.com"))
+	assert.Equal(t, http.StatusOK, serve("same-origin", "https://api.example.com"))
+
+	assert.Panics(t, func() { CSRF(CSRFOptions{TrustedOrigins: []string{"not a url"}}) })
+}
+
+// memoryCSRFStore 以固定的会话 ID 保存令牌，模拟服务端会话
+type memoryCSRFStore map[string]string
+
+func (s memoryCSRFStore) GetCSRFToken(r *http.Request) (string, error) {
+	return s[r.Header.Get("X-Session")], nil
+}
+
+func (s memoryCSRFStore) SaveCSRFToken(w http.ResponseWriter, r *http.Request, token string) error {
+	s[r.Header.Get("X-Session")] = token
+	return nil
+}
+
+func TestCSRF_Synchronizer(t *testing.T) {
+	store := memoryCSRFStore{}
+	h := csrfHandler(CSRFOptions{Store: store})
+
+	serve := func(method, session, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "https://example.com/", nil)
+		r.Header.Set("X-Session", session)
+		if token != "" {
+			r.Header.Set("X-CSRF-Token", token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("GET", "alice", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Result().Cookies(), "synchronizer mode does not use cookies")
+	token := w.Body.String()
+	require.NotEmpty(t, store["alice"])
+
+	assert.Equal(t, http.StatusOK, serve("DELETE", "alice", token).Code)
+	assert.Equal(t, http.StatusForbidden, serve("DELETE", "bob", token).Code, "token is bound to the session")
+	saved := store["alice"]
+	serve("GET", "alice", "")
+	assert.Equal(t, saved, store["alice"], "token is stable within a session")
+}
+
+func TestCSRFToken_Context(t *testing.T) {
+	assert.Empty(t, CSRFToken(context.Background()))
+
+	raw := make([]byte, csrfTokenSize)
+	raw[0] = 1
+	a, b := maskCSRFToken(raw), maskCSRFToken(raw)
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, raw, unmaskCSRFToken(a))
+	assert.Equal(t, raw, unmaskCSRFToken(b))
+}
@@ -0,0 +1,92 @@
+package httpx
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DeprecationOptions 配置废弃接口的响应头与下线行为。
+type DeprecationOptions struct {
+	// Since 接口被废弃的时间，输出为 Deprecation: @<unix> (RFC 9745)。
+	// RFC 9745 只允许日期形式，零值输出 Deprecation: @0 (Unix 纪元)，表示已废弃但未声明具体时间。
+	Since time.Time
+
+	// Sunset 接口计划下线的时间，输出为 Sunset: <HTTP-date> (RFC 8594)。零值不输出。
+	Sunset time.Time
+
+	// Link 迁移文档地址，输出为 Link: <...>; rel="deprecation"。
+	Link string
+
+	// GoneAfterSunset 为 true 时，Sunset 之后的请求直接返回 410 (ErrGone)，不再调用业务逻辑。
+	GoneAfterSunset bool
+
+	// OnCall 每次调用废弃接口时触发 (包括已下线返回 410 的请求)，
+	// 可用于统计仍在调用旧接口的客户端，例如按 r.Pattern 或 User-Agent 计数。
+	OnCall func(r *http.Request)
+}
+
+// deprecation 预先计算好的响应头，避免每次请求格式化时间
+type deprecation struct {
+	opts        DeprecationOptions
+	deprecation []string
+	sunset      []string
+	link        string
+}
+
+func newDeprecation(opts DeprecationOptions) *deprecation {
+	d := &deprecation{opts: opts}
+	var since int64
+	if !opts.Since.IsZero() {
+		since = opts.Since.Unix()
+	}
+	d.deprecation = []string{"@" + strconv.FormatInt(since, 10)}
+	if !opts.Sunset.IsZero() {
+		d.sunset = []string{opts.Sunset.UTC().Format(http.TimeFormat)}
+	}
+	if opts.Link != "" {
+		d.link = "<" + opts.Link + `>; rel="deprecation"`
+	}
+	return d
+}
+
+// serve 写入废弃相关的响应头并触发 OnCall。
+// 如果接口已下线，则通过 errFunc 渲染 ErrGone 并返回 false。
+func (d *deprecation) serve(w http.ResponseWriter, r *http.Request, errFunc ErrorFunc, opts ...ErrorOption) bool {
+	h := w.Header()
+	h["Deprecation"] = d.deprecation
+	if d.sunset != nil {
+		h["Sunset"] = d.sunset
+	}
+	if d.link != "" {
+		h.Add("Link", d.link)
+	}
+	if d.opts.OnCall != nil {
+		d.opts.OnCall(r)
+	}
+
+	if d.opts.GoneAfterSunset && !d.opts.Sunset.IsZero() && time.Now().After(d.opts.Sunset) {
+		errFunc(w, r, ErrGone, opts...)
+		return false
+	}
+	return true
+}
+
+// Deprecate 返回一个为接口标记废弃的中间件。
+// 它会输出 Deprecation、Sunset 与 Link 响应头，并在配置 GoneAfterSunset 时于下线后返回 410。
+// 对于 NewHandler 创建的单个接口，也可以使用 WithDeprecation 选项达到相同效果。
+func Deprecate(opts ...DeprecationOptions) Middleware {
+	var opt DeprecationOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	d := newDeprecation(opt)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if d.serve(w, r, Error) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecate(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+
+	t.Run("Headers", func(t *testing.T) {
+		var calls atomic.Int32
+		h := Deprecate(DeprecationOptions{
+			Since:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Sunset: time.Now().Add(24 * time.Hour),
+			Link:   "https://example.com/migrate",
+			OnCall: func(r *http.Request) { calls.Add(1) },
+		})(ok)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, "ok", w.Body.String())
+		assert.Equal(t, "@1704067200", w.Header().Get("Deprecation"))
+		assert.NotEmpty(t, w.Header().Get("Sunset"))
+		assert.Equal(t, `<https://example.com/migrate>; rel="deprecation"`, w.Header().Get("Link"))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("NoOptions", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		Deprecate()(ok).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, "@0", w.Header().Get("Deprecation"), "RFC 9745 has no date-less form")
+		assert.Empty(t, w.Header().Get("Sunset"))
+	})
+
+	t.Run("GoneAfterSunset", func(t *testing.T) {
+		var calls atomic.Int32
+		sunset := time.Date(2020, 6, 30, 0, 0, 0, 0, time.UTC)
+		h := Deprecate(DeprecationOptions{
+			Sunset:          sunset,
+			GoneAfterSunset: true,
+			OnCall:          func(r *http.Request) { calls.Add(1) },
+		})(ok)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusGone, w.Code)
+		assert.Contains(t, w.Body.String(), CodeGone)
+		assert.Equal(t, "Tue, 30 Jun 2020 00:00:00 GMT", w.Header().Get("Sunset"))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("SunsetWithoutGone", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		Deprecate(DeprecationOptions{Sunset: time.Unix(0, 0)})(ok).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, "ok", w.Body.String())
+	})
+}
+
+func TestWithDeprecation(t *testing.T) {
+	called := false
+	fn := func(ctx context.Context, req *TestReqEmpty) (*TestRes, error) {
+		called = true
+		return &TestRes{}, nil
+	}
+
+	w := httptest.NewRecorder()
+	NewHandler(fn, WithDeprecation(DeprecationOptions{Link: "https://example.com/v2"})).
+		ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "@0", w.Header().Get("Deprecation"))
+	assert.Equal(t, `<https://example.com/v2>; rel="deprecation"`, w.Header().Get("Link"))
+	assert.True(t, called)
+
+	called = false
+	w = httptest.NewRecorder()
+	NewHandler(fn, WithDeprecation(DeprecationOptions{Sunset: time.Unix(0, 0), GoneAfterSunset: true})).
+		ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.False(t, called)
+}
@@ -0,0 +1,139 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+type loggerKey struct{}
+
+// RequestLogger 返回一个中间件，它将预先填充了请求属性的 *slog.Logger 注入 Context。
+// 业务函数通过 LoggerFrom(ctx) 获取，无需在每个 HandlerFunc 中重复构造相同的属性。
+//
+// 预填充的属性: trace_id, route, client_ip, identity (存在时)。
+// 属性在中间件执行时计算，因此:
+//   - 通过 Router.With/Group 挂载时，route 即为匹配到的路由模式；
+//   - 需要 client_ip/identity 时，请将其放在 NewClientIPMiddleware 和 Auth 之后。
+//
+// base 为 nil 时使用 slog.Default()。
+func RequestLogger(base *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := base
+			if l == nil {
+				l = slog.Default()
+			}
+
+			ctx := r.Context()
+			state := getRequestState(ctx)
+
+			attrs := make([]any, 0, 4)
+			if GetTraceID != nil {
+				if traceID := GetTraceID(ctx); traceID != "" {
+					attrs = append(attrs, slog.String("trace_id", traceID))
+				}
+			}
+			if route := routeOf(r, state); route != "" {
+				attrs = append(attrs, slog.String("route", route))
+			}
+			if ip := ClientIP(ctx); ip != "" {
+				attrs = append(attrs, slog.String("client_ip", ip))
+			}
+			if id, ok := identityLogValue(GetIdentity(ctx)); ok {
+				attrs = append(attrs, slog.String("identity", id))
+			}
+
+			ctx = ContextWithLogger(ctx, l.With(attrs...))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ContextWithLogger 将 logger 存入 Context。
+func ContextWithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerFrom 从 Context 中获取请求级 logger。
+// 如果没有注入过 (未使用 RequestLogger)，返回 slog.Default()，因此总是可以安全调用。
+func LoggerFrom(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// stackTracer 由携带调用栈的错误实现 (例如 Recovery 产生的 panic 错误)。
+type stackTracer interface {
+	StackTrace() []byte
+}
+
+// LogErrorHook 是一个开箱即用的 ErrorHook 实现，通过 LoggerFrom(ctx) 记录错误。
+// 它会展开完整的 cause 链，并在错误携带调用栈时一并输出。
+// 4xx 错误以 Warn 级别记录，其余以 Error 级别记录。
+//
+// 用法:
+//
+//	httpx.ErrorHook = httpx.LogErrorHook
+func LogErrorHook(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+
+	level := slog.LevelError
+	var coder ErrorCoder
+	if errors.As(err, &coder) {
+		if status := coder.HTTPStatus(); status >= 400 && status < 500 {
+			level = slog.LevelWarn
+		}
+	}
+
+	l := LoggerFrom(ctx)
+	if !l.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 4)
+	attrs = append(attrs,
+		slog.String("error", err.Error()),
+		slog.String("error_type", fmt.Sprintf("%T", err)),
+	)
+	if causes := causeChain(err); len(causes) > 0 {
+		attrs = append(attrs, slog.Any("causes", causes))
+	}
+	var st stackTracer
+	if errors.As(err, &st) {
+		if stack := st.StackTrace(); len(stack) > 0 {
+			attrs = append(attrs, slog.String("stack", string(stack)))
+		}
+	}
+
+	l.LogAttrs(ctx, level, "request error", attrs...)
+}
+
+// causeChain 按深度优先展开被包装的错误 (支持 errors.Join 产生的多重包装)。
+func causeChain(err error) []string {
+	var causes []string
+	var walk func(error)
+	walk = func(e error) {
+		switch x := e.(type) {
+		case interface{ Unwrap() error }:
+			if inner := x.Unwrap(); inner != nil {
+				causes = append(causes, inner.Error())
+				walk(inner)
+			}
+		case interface{ Unwrap() []error }:
+			for _, inner := range x.Unwrap() {
+				if inner != nil {
+					causes = append(causes, inner.Error())
+					walk(inner)
+				}
+			}
+		}
+	}
+	walk(err)
+	return causes
+}
@@ -0,0 +1,111 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogger_Injection(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	router := NewRouter()
+	api := router.With(
+		NewClientIPMiddleware(nil),
+		Auth(func(w http.ResponseWriter, r *http.Request) (any, error) { return "user-7", nil }),
+		RequestLogger(base),
+	)
+	api.Handle("GET /items/{id}", NewHandler(func(ctx context.Context, req *TestReqEmpty) (*TestRes, error) {
+		LoggerFrom(ctx).Info("loading item")
+		return &TestRes{ID: "1"}, nil
+	}))
+
+	r := httptest.NewRequest("GET", "/items/1", nil)
+	r.RemoteAddr = "198.51.100.7:5555"
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := decodeLogLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "loading item", lines[0]["msg"])
+	assert.Equal(t, "GET /items/{id}", lines[0]["route"])
+	assert.Equal(t, "198.51.100.7", lines[0]["client_ip"])
+	assert.Equal(t, "user-7", lines[0]["identity"])
+}
+
+func TestLoggerFrom_Default(t *testing.T) {
+	assert.Same(t, slog.Default(), LoggerFrom(context.Background()))
+}
+
+type tracedErr struct{ msg string }
+
+func (e *tracedErr) Error() string      { return e.msg }
+func (e *tracedErr) StackTrace() []byte { return []byte("goroutine 1 [running]:\nmain.main()") }
+
+func TestLogErrorHook(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := ContextWithLogger(context.Background(), slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	t.Run("CauseChainAndStack", func(t *testing.T) {
+		buf.Reset()
+		root := &tracedErr{msg: "connection refused"}
+		err := fmt.Errorf("load user: %w", errors.Join(errors.New("retry exhausted"), root))
+		LogErrorHook(ctx, err)
+
+		lines := decodeLogLines(t, &buf)
+		require.Len(t, lines, 1)
+		assert.Equal(t, "ERROR", lines[0]["level"])
+		assert.Equal(t, err.Error(), lines[0]["error"])
+		causes := lines[0]["causes"].([]any)
+		assert.Contains(t, causes, "retry exhausted")
+		assert.Contains(t, causes, "connection refused")
+		assert.Contains(t, lines[0]["stack"], "goroutine 1")
+	})
+
+	t.Run("ClientErrorIsWarn", func(t *testing.T) {
+		buf.Reset()
+		LogErrorHook(ctx, ErrNotFound)
+
+		lines := decodeLogLines(t, &buf)
+		require.Len(t, lines, 1)
+		assert.Equal(t, "WARN", lines[0]["level"])
+		assert.Nil(t, lines[0]["causes"])
+	})
+
+	t.Run("ViaError", func(t *testing.T) {
+		buf.Reset()
+		r := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+		Error(httptest.NewRecorder(), r, errors.New("boom"), WithHook(LogErrorHook))
+
+		lines := decodeLogLines(t, &buf)
+		require.Len(t, lines, 1)
+		assert.Equal(t, "boom", lines[0]["error"])
+	})
+}
+
+func TestRequestLogger_IdentitySubjectOnly(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	user := &testSubjectIdentity{ID: "user-7", Email: "a@example.com"}
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		LoggerFrom(r.Context()).Info("charging")
+	}),
+		Auth(func(w http.ResponseWriter, r *http.Request) (any, error) { return user, nil }),
+		RequestLogger(base),
+	)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/charges", nil))
+
+	lines := decodeLogLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "user-7", lines[0]["identity"])
+	assert.NotContains(t, buf.String(), "example.com")
+}
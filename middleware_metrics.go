@@ -0,0 +1,410 @@
+package httpx
+
+import (
+	"bufio"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// 默认的直方图桶，与 Prometheus 客户端库的默认值保持一致，方便直接复用现有看板。
+var (
+	DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	DefaultSizeBuckets     = []float64{100, 1000, 10000, 100000, 1e6, 1e7}
+)
+
+// MetricsOptions 定义指标采集配置
+type MetricsOptions struct {
+	// Namespace 指标名前缀，默认为 "httpx"
+	Namespace string
+
+	// DurationBuckets 请求耗时直方图的桶上界 (秒)
+	DurationBuckets []float64
+
+	// SizeBuckets 响应体大小直方图的桶上界 (字节)
+	SizeBuckets []float64
+}
+
+// Metrics 聚合 HTTP 请求指标，并以 Prometheus 文本格式暴露。
+// 标签使用 r.Pattern (路由模式) 而不是原始路径，避免 /users/1, /users/2 ... 造成标签基数爆炸。
+//
+// 用法:
+//
+//	m := httpx.NewMetrics()
+//	handler := httpx.Chain(router, m.Middleware)
+//	mux.Handle("GET /metrics", m.Handler())
+type Metrics struct {
+	namespace       string
+	durationBuckets []float64
+	sizeBuckets     []float64
+
+	requests *xsync.Map[metricsKey, *requestSeries]
+	inFlight *xsync.Map[string, *atomic.Int64]
+	panics   *xsync.Map[panicKey, *atomic.Uint64]
+}
+
+type panicKey struct {
+	route string
+	kind  PanicKind
+}
+
+// metricsKey 是一组请求指标的标签
+type metricsKey struct {
+	method string
+	route  string
+	status string
+	code   string
+}
+
+type requestSeries struct {
+	count    atomic.Uint64
+	duration *histogram
+	size     *histogram
+}
+
+// NewMetrics 创建一个指标收集器。
+func NewMetrics(opts ...MetricsOptions) *Metrics {
+	var opt MetricsOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Namespace == "" {
+		opt.Namespace = "httpx"
+	}
+	if len(opt.DurationBuckets) == 0 {
+		opt.DurationBuckets = DefaultDurationBuckets
+	}
+	if len(opt.SizeBuckets) == 0 {
+		opt.SizeBuckets = DefaultSizeBuckets
+	}
+
+	return &Metrics{
+		namespace:       opt.Namespace,
+		durationBuckets: sortedBuckets(opt.DurationBuckets),
+		sizeBuckets:     sortedBuckets(opt.SizeBuckets),
+		requests:        xsync.NewMap[metricsKey, *requestSeries](),
+		inFlight:        xsync.NewMap[string, *atomic.Int64](),
+		panics:          xsync.NewMap[panicKey, *atomic.Uint64](),
+	}
+}
+
+// Middleware 返回一个记录请求指标的中间件。
+// 它应该放在 Router 之外，以便同时统计 404/405 等未命中路由的请求。
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, state := withRequestState(r)
+		method := normalizeMethod(r.Method)
+
+		gauge, _ := m.inFlight.LoadOrCompute(method, func() (*atomic.Int64, bool) {
+			return new(atomic.Int64), false
+		})
+		gauge.Add(1)
+		defer gauge.Add(-1)
+
+		snoop := httpsnoop.CaptureMetrics(next, w, r)
+
+		key := metricsKey{
+			method: method,
+			route:  routeOf(r, state),
+			status: statusClass(snoop.Code),
+			code:   state.bizCode,
+		}
+		series, _ := m.requests.LoadOrCompute(key, func() (*requestSeries, bool) {
+			return &requestSeries{
+				duration: newHistogram(m.durationBuckets),
+				size:     newHistogram(m.sizeBuckets),
+			}, false
+		})
+		series.count.Add(1)
+		series.duration.observe(snoop.Duration.Seconds())
+		series.size.observe(float64(snoop.Written))
+	})
+}
+
+// PanicHook 返回一个统计 panic 次数的钩子，配合 Recovery 使用:
+//
+//	httpx.Recovery(httpx.WithPanicHook(m.PanicHook()))
+func (m *Metrics) PanicHook() func(r *http.Request, p *PanicError) {
+	return func(r *http.Request, p *PanicError) {
+		key := panicKey{route: routeOf(r, getRequestState(r.Context())), kind: p.Kind}
+		counter, _ := m.panics.LoadOrCompute(key, func() (*atomic.Uint64, bool) {
+			return new(atomic.Uint64), false
+		})
+		counter.Add(1)
+	}
+}
+
+// Handler 返回一个以 Prometheus 文本格式 (version 0.0.4) 输出当前指标的 Handler。
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header()["Content-Type"] = []string{"text/plain; version=0.0.4; charset=utf-8"}
+		bw := bufio.NewWriter(w)
+		m.writeTo(bw)
+		_ = bw.Flush()
+	})
+}
+
+func (m *Metrics) writeTo(w *bufio.Writer) {
+	keys := make([]metricsKey, 0, m.requests.Size())
+	m.requests.Range(func(k metricsKey, _ *requestSeries) bool {
+		keys = append(keys, k)
+		return true
+	})
+	slices.SortFunc(keys, func(a, b metricsKey) int {
+		if c := strings.Compare(a.route, b.route); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.method, b.method); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.status, b.status); c != 0 {
+			return c
+		}
+		return strings.Compare(a.code, b.code)
+	})
+
+	// 1. 请求总数
+	name := m.namespace + "_requests_total"
+	writeMetaLine(w, name, "counter", "Total number of HTTP requests.")
+	for _, k := range keys {
+		series, ok := m.requests.Load(k)
+		if !ok {
+			continue
+		}
+		w.WriteString(name)
+		writeLabels(w, k, "")
+		w.WriteByte(' ')
+		w.WriteString(strconv.FormatUint(series.count.Load(), 10))
+		w.WriteByte('\n')
+	}
+
+	// 2. 请求耗时
+	name = m.namespace + "_request_duration_seconds"
+	writeMetaLine(w, name, "histogram", "HTTP request latency in seconds.")
+	for _, k := range keys {
+		if series, ok := m.requests.Load(k); ok {
+			series.duration.writeTo(w, name, k)
+		}
+	}
+
+	// 3. 响应大小
+	name = m.namespace + "_response_size_bytes"
+	writeMetaLine(w, name, "histogram", "HTTP response body size in bytes.")
+	for _, k := range keys {
+		if series, ok := m.requests.Load(k); ok {
+			series.size.writeTo(w, name, k)
+		}
+	}
+
+	// 4. 正在处理的请求数
+	methods := make([]string, 0, m.inFlight.Size())
+	m.inFlight.Range(func(k string, _ *atomic.Int64) bool {
+		methods = append(methods, k)
+		return true
+	})
+	slices.Sort(methods)
+
+	name = m.namespace + "_requests_in_flight"
+	writeMetaLine(w, name, "gauge", "Number of HTTP requests currently being served.")
+	for _, method := range methods {
+		gauge, ok := m.inFlight.Load(method)
+		if !ok {
+			continue
+		}
+		w.WriteString(name)
+		w.WriteString(`{method="`)
+		writeLabelValue(w, method)
+		w.WriteString(`"} `)
+		w.WriteString(strconv.FormatInt(gauge.Load(), 10))
+		w.WriteByte('\n')
+	}
+
+	// 5. panic 次数
+	panics := make([]panicKey, 0, m.panics.Size())
+	m.panics.Range(func(k panicKey, _ *atomic.Uint64) bool {
+		panics = append(panics, k)
+		return true
+	})
+	if len(panics) == 0 {
+		return
+	}
+	slices.SortFunc(panics, func(a, b panicKey) int {
+		if c := strings.Compare(a.route, b.route); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.kind), string(b.kind))
+	})
+
+	name = m.namespace + "_panics_total"
+	writeMetaLine(w, name, "counter", "Total number of panics recovered while serving HTTP requests.")
+	for _, k := range panics {
+		counter, ok := m.panics.Load(k)
+		if !ok {
+			continue
+		}
+		w.WriteString(name)
+		w.WriteString(`{route="`)
+		writeLabelValue(w, k.route)
+		w.WriteString(`",kind="`)
+		writeLabelValue(w, string(k.kind))
+		w.WriteString(`"} `)
+		w.WriteString(strconv.FormatUint(counter.Load(), 10))
+		w.WriteByte('\n')
+	}
+}
+
+// ---------------------------------------------------------------------------
+// histogram: 无锁直方图
+// ---------------------------------------------------------------------------
+
+type histogram struct {
+	upper  []float64
+	counts []atomic.Uint64 // 每个桶的非累积计数，写出时再累加
+	count  atomic.Uint64
+	sum    atomic.Uint64 // float64 bits
+}
+
+func newHistogram(upper []float64) *histogram {
+	return &histogram{
+		upper:  upper,
+		counts: make([]atomic.Uint64, len(upper)),
+	}
+}
+
+func (h *histogram) observe(v float64) {
+	// 桶数量很少，线性查找比二分更快
+	for i, u := range h.upper {
+		if v <= u {
+			h.counts[i].Add(1)
+			break
+		}
+	}
+	h.count.Add(1)
+	for {
+		old := h.sum.Load()
+		if h.sum.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (h *histogram) writeTo(w *bufio.Writer, name string, k metricsKey) {
+	var cumulative uint64
+	for i, u := range h.upper {
+		cumulative += h.counts[i].Load()
+		w.WriteString(name)
+		w.WriteString("_bucket")
+		writeLabels(w, k, strconv.FormatFloat(u, 'g', -1, 64))
+		w.WriteByte(' ')
+		w.WriteString(strconv.FormatUint(cumulative, 10))
+		w.WriteByte('\n')
+	}
+	count := h.count.Load()
+	w.WriteString(name)
+	w.WriteString("_bucket")
+	writeLabels(w, k, "+Inf")
+	w.WriteByte(' ')
+	w.WriteString(strconv.FormatUint(count, 10))
+	w.WriteByte('\n')
+
+	w.WriteString(name)
+	w.WriteString("_sum")
+	writeLabels(w, k, "")
+	w.WriteByte(' ')
+	w.WriteString(strconv.FormatFloat(math.Float64frombits(h.sum.Load()), 'g', -1, 64))
+	w.WriteByte('\n')
+
+	w.WriteString(name)
+	w.WriteString("_count")
+	writeLabels(w, k, "")
+	w.WriteByte(' ')
+	w.WriteString(strconv.FormatUint(count, 10))
+	w.WriteByte('\n')
+}
+
+// ---------------------------------------------------------------------------
+// 文本格式辅助函数
+// ---------------------------------------------------------------------------
+
+func writeMetaLine(w *bufio.Writer, name, typ, help string) {
+	w.WriteString("# HELP ")
+	w.WriteString(name)
+	w.WriteByte(' ')
+	w.WriteString(help)
+	w.WriteString("\n# TYPE ")
+	w.WriteString(name)
+	w.WriteByte(' ')
+	w.WriteString(typ)
+	w.WriteByte('\n')
+}
+
+func writeLabels(w *bufio.Writer, k metricsKey, le string) {
+	w.WriteString(`{method="`)
+	writeLabelValue(w, k.method)
+	w.WriteString(`",route="`)
+	writeLabelValue(w, k.route)
+	w.WriteString(`",status="`)
+	writeLabelValue(w, k.status)
+	w.WriteString(`",code="`)
+	writeLabelValue(w, k.code)
+	if le != "" {
+		w.WriteString(`",le="`)
+		w.WriteString(le)
+	}
+	w.WriteString(`"}`)
+}
+
+// writeLabelValue 按照文本格式规范转义标签值中的 \, " 和换行符。
+func writeLabelValue(w *bufio.Writer, v string) {
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; c {
+		case '\\':
+			w.WriteString(`\\`)
+		case '"':
+			w.WriteString(`\"`)
+		case '\n':
+			w.WriteString(`\n`)
+		default:
+			w.WriteByte(c)
+		}
+	}
+}
+
+// normalizeMethod 将非标准方法归并为 "OTHER"，防止恶意方法名撑爆标签。
+func normalizeMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// statusClass 将状态码折叠为 "2xx", "4xx" 等类别
+func statusClass(code int) string {
+	switch {
+	case code >= 100 && code < 200:
+		return "1xx"
+	case code < 300:
+		return "2xx"
+	case code < 400:
+		return "3xx"
+	case code < 500:
+		return "4xx"
+	default:
+		return "5xx"
+	}
+}
+
+func sortedBuckets(b []float64) []float64 {
+	out := slices.Clone(b)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
@@ -0,0 +1,111 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_RoutePatternLabels(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+	router.Handle("POST /users", NewHandler(func(ctx context.Context, req *TestReqEmpty) (*TestRes, error) {
+		return nil, errors.New("db down")
+	}))
+
+	m := NewMetrics()
+	// ClientIP 中间件会复制请求，用于验证路由模式能够穿过 r.WithContext 回传
+	h := Chain(router, m.Middleware, NewClientIPMiddleware(nil))
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/3"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/users", nil))
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, body, "# TYPE httpx_requests_total counter")
+	assert.Contains(t, body, `httpx_requests_total{method="GET",route="GET /users/{id}",status="2xx",code=""} 3`)
+	assert.Contains(t, body, `httpx_requests_total{method="POST",route="POST /users",status="5xx",code="INTERNAL_ERROR"} 1`)
+	assert.Contains(t, body, `httpx_request_duration_seconds_count{method="GET",route="GET /users/{id}",status="2xx",code=""} 3`)
+	assert.Contains(t, body, `httpx_response_size_bytes_bucket{method="GET",route="GET /users/{id}",status="2xx",code="",le="100"} 3`)
+	assert.Contains(t, body, `httpx_response_size_bytes_sum{method="GET",route="GET /users/{id}",status="2xx",code=""} 15`)
+	assert.Contains(t, body, `httpx_requests_in_flight{method="GET"} 0`)
+	assert.NotContains(t, body, "/users/1")
+}
+
+func TestMetrics_Options(t *testing.T) {
+	m := NewMetrics(MetricsOptions{Namespace: "api", DurationBuckets: []float64{1, 0.5, 1}})
+	h := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("BREW", "/pot", nil))
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	// 非标准方法被折叠，桶被排序去重
+	assert.Contains(t, body, `api_requests_total{method="OTHER",route="",status="4xx",code=""} 1`)
+	assert.Contains(t, body, `api_request_duration_seconds_bucket{method="OTHER",route="",status="4xx",code="",le="0.5"} 1`)
+	assert.Contains(t, body, `api_request_duration_seconds_bucket{method="OTHER",route="",status="4xx",code="",le="+Inf"} 1`)
+	assert.Equal(t, 1, strings.Count(body, `le="1"`))
+}
+
+func TestMetrics_PanickingRouteKeepsPattern(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("GET /boom/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	m := NewMetrics()
+	// Recovery 与 Metrics 位于 Router 之外，ClientIP 复制了请求，路由模式只能在 panic 展开时经 requestState 回传
+	h := Chain(router,
+		m.Middleware,
+		Recovery(WithHook(func(context.Context, error) {}), WithPanicHook(m.PanicHook())),
+		NewClientIPMiddleware(nil),
+	)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/boom/1", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	w = httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	assert.Contains(t, body, `httpx_requests_total{method="GET",route="GET /boom/{id}",status="5xx",code="INTERNAL_ERROR"} 1`)
+	assert.Contains(t, body, `httpx_panics_total{route="GET /boom/{id}",kind="value"} 1`)
+}
+
+func TestMetrics_StreamAndResponderCodeOK(t *testing.T) {
+	router := NewRouter()
+	router.Handle("GET /file", NewStreamHandler(func(ctx context.Context, req *TestReqEmpty) (*FileResponse, error) {
+		return &FileResponse{Content: strings.NewReader("data")}, nil
+	}))
+	router.Handle("GET /go", NewResponder(func(ctx context.Context, req *TestReqEmpty) (Responder, error) {
+		return Redirect{URL: "/file", Code: http.StatusFound}, nil
+	}))
+
+	m := NewMetrics()
+	h := Chain(router, m.Middleware)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/file", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/go", nil))
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	assert.Contains(t, body, `httpx_requests_total{method="GET",route="GET /file",status="2xx",code="OK"} 1`)
+	assert.Contains(t, body, `httpx_requests_total{method="GET",route="GET /go",status="3xx",code="OK"} 1`)
+}
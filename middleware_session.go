@@ -0,0 +1,468 @@
+package httpx
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// sessionIDSize 会话 ID 的随机字节数
+const sessionIDSize = 32
+
+// SessionRecord 是会话在存储中的内容。
+type SessionRecord struct {
+	Values     map[string]any
+	CreatedAt  time.Time
+	AccessedAt time.Time
+}
+
+// SessionStore 保存会话数据。
+// Load 在会话不存在或已过期时返回 (nil, nil)；返回的 error 仅用于表示存储故障。
+// ttl 是存储可以自行淘汰该会话的时间，外部存储 (如 Redis) 可以直接用作过期时间。
+type SessionStore interface {
+	Load(ctx context.Context, id string) (*SessionRecord, error)
+	Save(ctx context.Context, id string, rec *SessionRecord, ttl time.Duration) error
+	Delete(ctx context.Context, id string) error
+}
+
+// MemorySessionStore 是基于内存的 SessionStore，仅适用于单实例部署。
+type MemorySessionStore struct {
+	sessions *xsync.Map[string, memorySession]
+	ops      atomic.Uint64
+}
+
+type memorySession struct {
+	rec     SessionRecord
+	expires time.Time
+}
+
+// NewMemorySessionStore 创建一个内存 SessionStore。
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: xsync.NewMap[string, memorySession]()}
+}
+
+func (s *MemorySessionStore) Load(ctx context.Context, id string) (*SessionRecord, error) {
+	e, ok := s.sessions.Load(id)
+	if !ok {
+		return nil, nil
+	}
+	if !time.Now().Before(e.expires) {
+		s.sessions.Delete(id)
+		return nil, nil
+	}
+	// 返回副本，业务代码对会话的修改在 Save 之前不会影响存储
+	rec := e.rec
+	rec.Values = maps.Clone(rec.Values)
+	return &rec, nil
+}
+
+func (s *MemorySessionStore) Save(ctx context.Context, id string, rec *SessionRecord, ttl time.Duration) error {
+	now := time.Now()
+	// 每 1024 次写入顺带清理一次过期的会话，避免无限增长
+	if s.ops.Add(1)%1024 == 0 {
+		s.sessions.Range(func(k string, e memorySession) bool {
+			if !now.Before(e.expires) {
+				s.sessions.Delete(k)
+			}
+			return true
+		})
+	}
+	e := memorySession{rec: *rec, expires: now.Add(ttl)}
+	e.rec.Values = maps.Clone(rec.Values)
+	s.sessions.Store(id, e)
+	return nil
+}
+
+func (s *MemorySessionStore) Delete(ctx context.Context, id string) error {
+	s.sessions.Delete(id)
+	return nil
+}
+
+// SessionOptions 配置会话管理。
+type SessionOptions struct {
+	// Store 会话存储。默认 NewMemorySessionStore()。
+	Store SessionStore
+
+	// CookieName 保存会话 ID 的 Cookie 名称。默认 "session_id"。
+	// Cookie 通过 SetCookie 写入，默认 HttpOnly、Secure、SameSite=Lax 并带 __Host- 前缀。
+	CookieName string
+
+	// CookieOptions 会话 Cookie 的额外选项。默认是浏览器会话 Cookie (关闭浏览器即失效)；
+	// 需要持久登录时可加上 WithCookieTTL，每次保存会话时都会刷新其有效期。
+	CookieOptions []CookieOption
+
+	// IdleTimeout 空闲超时：超过该时间没有访问会话即失效。默认 30 分钟。
+	IdleTimeout time.Duration
+
+	// AbsoluteTimeout 绝对超时：无论是否活跃，会话在创建后超过该时间即失效。默认 24 小时。
+	AbsoluteTimeout time.Duration
+}
+
+type sessionKey struct{}
+
+// Session 返回 Sessions 中间件为当前请求准备的会话。未经过 Sessions 中间件时返回 nil。
+func Session(ctx context.Context) *SessionData {
+	s, _ := ctx.Value(sessionKey{}).(*SessionData)
+	return s
+}
+
+// SessionValue 读取会话中的值并断言为 T。
+//
+//	userID, ok := httpx.SessionValue[string](ctx, "user_id")
+func SessionValue[T any](ctx context.Context, key string) (T, bool) {
+	var zero T
+	s := Session(ctx)
+	if s == nil {
+		return zero, false
+	}
+	v, ok := s.Get(key).(T)
+	return v, ok
+}
+
+// SessionData 是一个请求中的会话。
+// 会话在第一次访问时才从存储加载，只有被修改 (或需要刷新空闲计时) 时才会保存；
+// 保存发生在响应头写出之前，因此业务代码无需关心调用顺序。
+// 从未被访问的会话不会刷新空闲计时。
+type SessionData struct {
+	mu   sync.Mutex
+	opts *SessionOptions
+	ctx  context.Context
+
+	cookieID  string // 请求携带的有效会话 ID
+	hadCookie bool   // 请求是否携带了会话 Cookie (包括格式无效的)
+	id        string // 当前会话 ID，新会话在第一次写入前为空
+	oldID     string // 需要在保存时删除的旧会话 ID (轮换、销毁或超时)
+	values    map[string]any
+
+	createdAt  time.Time
+	accessedAt time.Time
+
+	loaded    bool
+	dirty     bool
+	committed bool
+	err       error
+}
+
+// ID 返回当前的会话 ID。尚未保存过的新会话返回空字符串。
+func (s *SessionData) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return s.id
+}
+
+// Err 返回加载会话时存储发生的错误。
+// 此时会话表现为空会话，并且不会被保存，以免覆盖存储中的数据。
+func (s *SessionData) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return s.err
+}
+
+// CreatedAt 返回会话的创建时间。
+func (s *SessionData) CreatedAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return s.createdAt
+}
+
+// Get 返回 key 对应的值，不存在时返回 nil。
+func (s *SessionData) Get(key string) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return s.values[key]
+}
+
+// Set 设置 key 对应的值。值需要能被所用的 SessionStore 序列化。
+func (s *SessionData) Set(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	s.ensureID()
+	s.values[key] = value
+	s.dirty = true
+}
+
+// Delete 删除 key。
+func (s *SessionData) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.dirty = true
+	}
+}
+
+// Rotate 为会话分配新的 ID 并保留其中的数据，旧 ID 随即失效。
+// 应在权限变化 (登录、提权、切换账号) 时调用，防御会话固定攻击。
+func (s *SessionData) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	id, err := newSessionID()
+	if err != nil {
+		return err
+	}
+	if s.id != "" && s.oldID == "" {
+		s.oldID = s.id
+	}
+	if s.createdAt.IsZero() {
+		s.createdAt = time.Now()
+	}
+	s.id = id
+	s.dirty = true
+	return nil
+}
+
+// Destroy 删除会话数据并清除 Cookie (如登出)。之后调用 Set 会开始一个新会话。
+func (s *SessionData) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	if s.id != "" && s.oldID == "" {
+		s.oldID = s.id
+	}
+	s.id = ""
+	s.values = map[string]any{}
+	s.dirty = false
+}
+
+// load 从存储加载会话 (只执行一次)，调用方需持有锁
+func (s *SessionData) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	s.values = map[string]any{}
+	if s.cookieID == "" {
+		return
+	}
+
+	rec, err := s.opts.Store.Load(s.ctx, s.cookieID)
+	if err != nil {
+		s.err = err
+		reportSessionError(s.ctx, err)
+		return
+	}
+	if rec == nil {
+		return
+	}
+	now := time.Now()
+	if now.Sub(rec.AccessedAt) >= s.opts.IdleTimeout || now.Sub(rec.CreatedAt) >= s.opts.AbsoluteTimeout {
+		// 超时的会话不再使用，保存时从存储中删除
+		s.oldID = s.cookieID
+		return
+	}
+	s.id = s.cookieID
+	if rec.Values != nil {
+		s.values = rec.Values
+	}
+	s.createdAt = rec.CreatedAt
+	s.accessedAt = rec.AccessedAt
+}
+
+// ensureID 在第一次写入时为新会话分配 ID，调用方需持有锁
+func (s *SessionData) ensureID() {
+	if s.id != "" {
+		return
+	}
+	id, err := newSessionID()
+	if err != nil {
+		s.err = err
+		return
+	}
+	s.id = id
+	s.createdAt = time.Now()
+}
+
+// commit 在响应头写出之前保存会话并写入 Cookie，只执行一次
+func (s *SessionData) commit(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.committed || !s.loaded || s.err != nil {
+		return
+	}
+	s.committed = true
+	store := s.opts.Store
+
+	if s.oldID != "" {
+		if err := store.Delete(s.ctx, s.oldID); err != nil {
+			reportSessionError(s.ctx, err)
+		}
+	}
+	if s.id == "" {
+		// 销毁、超时或无效的会话：清除客户端残留的 Cookie
+		if s.hadCookie {
+			// DelCookie 会应用 opts，因此追加一个负的 TTL，确保 WithCookieTTL 不会覆盖 MaxAge
+			DelCookie(w, s.opts.CookieName, slices.Concat(s.opts.CookieOptions, []CookieOption{WithCookieTTL(-time.Second)})...)
+		}
+		return
+	}
+
+	now := time.Now()
+	// 未修改的会话每经过 1/10 个空闲超时才保存一次，以刷新空闲计时
+	if !s.dirty && now.Sub(s.accessedAt) < s.opts.IdleTimeout/10 {
+		return
+	}
+	ttl := min(s.opts.IdleTimeout, s.createdAt.Add(s.opts.AbsoluteTimeout).Sub(now))
+	if ttl <= 0 {
+		return
+	}
+	s.accessedAt = now
+	rec := &SessionRecord{Values: s.values, CreatedAt: s.createdAt, AccessedAt: now}
+	if err := store.Save(s.ctx, s.id, rec, ttl); err != nil {
+		reportSessionError(s.ctx, err)
+		return
+	}
+	SetCookie(w, s.opts.CookieName, s.id, s.opts.CookieOptions...)
+}
+
+func reportSessionError(ctx context.Context, err error) {
+	if ErrorHook != nil {
+		ErrorHook(ctx, err)
+	}
+}
+
+func newSessionID() (string, error) {
+	var buf [sessionIDSize]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf[:]), nil
+}
+
+// validSessionID 拒绝格式不符的 ID，避免把任意客户端输入交给存储
+func validSessionID(id string) bool {
+	buf, err := base64.RawURLEncoding.DecodeString(id)
+	return err == nil && len(buf) == sessionIDSize
+}
+
+// Sessions 创建服务端会话中间件。业务代码通过 Session(ctx) 访问会话：
+//
+//	mw := httpx.Sessions(httpx.SessionOptions{IdleTimeout: time.Hour})
+//
+//	func login(ctx context.Context, req *LoginReq) (*LoginRes, error) {
+//		s := httpx.Session(ctx)
+//		if err := s.Rotate(); err != nil { // 登录是一次权限变化
+//			return nil, err
+//		}
+//		s.Set("user_id", user.ID)
+//		...
+//	}
+//
+// 存储故障会交给 ErrorHook 记录，请求本身不会因此失败 (参见 SessionData.Err)。
+func Sessions(opts SessionOptions) Middleware {
+	if opts.Store == nil {
+		opts.Store = NewMemorySessionStore()
+	}
+	if opts.CookieName == "" {
+		opts.CookieName = "session_id"
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = 30 * time.Minute
+	}
+	if opts.AbsoluteTimeout <= 0 {
+		opts.AbsoluteTimeout = 24 * time.Hour
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s := &SessionData{opts: &opts, ctx: r.Context()}
+			if id, err := GetCookie(r, opts.CookieName); err == nil {
+				// 伪造或损坏的 Cookie 不会交给存储，按过期会话处理，在首次访问后清除
+				s.hadCookie = true
+				if validSessionID(id) {
+					s.cookieID = id
+				}
+			}
+			r = r.WithContext(context.WithValue(r.Context(), sessionKey{}, s))
+			s.ctx = r.Context()
+
+			// 在任何响应内容写出之前保存会话，Set-Cookie 才能进入响应头
+			ww := httpsnoop.Wrap(w, httpsnoop.Hooks{
+				WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+					return func(code int) {
+						// 1xx 信息性响应 (如 103 Early Hints) 之后仍然可以修改响应头
+						if code >= 200 {
+							s.commit(w)
+						}
+						next(code)
+					}
+				},
+				Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+					return func(b []byte) (int, error) {
+						s.commit(w)
+						return next(b)
+					}
+				},
+				ReadFrom: func(next httpsnoop.ReadFromFunc) httpsnoop.ReadFromFunc {
+					return func(src io.Reader) (int64, error) {
+						s.commit(w)
+						return next(src)
+					}
+				},
+				Flush: func(next httpsnoop.FlushFunc) httpsnoop.FlushFunc {
+					return func() {
+						s.commit(w)
+						next()
+					}
+				},
+				Hijack: func(next httpsnoop.HijackFunc) httpsnoop.HijackFunc {
+					return func() (net.Conn, *bufio.ReadWriter, error) {
+						s.commit(w)
+						return next()
+					}
+				},
+			})
+
+			next.ServeHTTP(ww, r)
+			// 没有写出任何内容的响应 (隐式 200)
+			s.commit(w)
+		})
+	}
+}
+
+// sessionCSRFKey 是 SessionCSRFStore 在会话中保存令牌的 key
+const sessionCSRFKey = "_csrf"
+
+// SessionCSRFStore 将 CSRF 同步令牌保存在 Sessions 会话中，需要放在 Sessions 中间件之后：
+//
+//	httpx.Chain(h, httpx.Sessions(sessOpts), httpx.CSRF(httpx.CSRFOptions{Store: httpx.SessionCSRFStore{}}))
+type SessionCSRFStore struct{}
+
+var errNoSession = errors.New("httpx: SessionCSRFStore requires the Sessions middleware")
+
+func (SessionCSRFStore) GetCSRFToken(r *http.Request) (string, error) {
+	s := Session(r.Context())
+	if s == nil {
+		return "", errNoSession
+	}
+	token, _ := s.Get(sessionCSRFKey).(string)
+	return token, s.Err()
+}
+
+func (SessionCSRFStore) SaveCSRFToken(w http.ResponseWriter, r *http.Request, token string) error {
+	s := Session(r.Context())
+	if s == nil {
+		return errNoSession
+	}
+	s.Set(sessionCSRFKey, token)
+	return nil
+}
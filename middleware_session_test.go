@@ -0,0 +1,310 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingSessionStore 统计对底层存储的调用次数，用于验证懒加载与按需保存
+type countingSessionStore struct {
+	*MemorySessionStore
+	loads, saves, deletes atomic.Int32
+	loadErr               error
+}
+
+func (s *countingSessionStore) Load(ctx context.Context, id string) (*SessionRecord, error) {
+	s.loads.Add(1)
+	if s.loadErr != nil {
+		return nil, s.loadErr
+	}
+	return s.MemorySessionStore.Load(ctx, id)
+}
+
+func (s *countingSessionStore) Save(ctx context.Context, id string, rec *SessionRecord, ttl time.Duration) error {
+	s.saves.Add(1)
+	return s.MemorySessionStore.Save(ctx, id, rec, ttl)
+}
+
+func (s *countingSessionStore) Delete(ctx context.Context, id string) error {
+	s.deletes.Add(1)
+	return s.MemorySessionStore.Delete(ctx, id)
+}
+
+func newCountingSessionStore() *countingSessionStore {
+	return &countingSessionStore{MemorySessionStore: NewMemorySessionStore()}
+}
+
+// sessionClient 模拟浏览器：保存响应中的会话 Cookie 并在后续请求中携带
+type sessionClient struct {
+	t       *testing.T
+	handler http.Handler
+	cookie  *http.Cookie
+}
+
+func (c *sessionClient) do(path string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", "https://example.com"+path, nil)
+	if c.cookie != nil {
+		r.AddCookie(c.cookie)
+	}
+	w := httptest.NewRecorder()
+	c.handler.ServeHTTP(w, r)
+	for _, ck := range w.Result().Cookies() {
+		if ck.Name != "__Host-session_id" {
+			continue
+		}
+		if ck.MaxAge < 0 {
+			c.cookie = nil
+		} else {
+			c.cookie = ck
+		}
+	}
+	return w
+}
+
+func sessionTestHandler(opts SessionOptions) http.Handler {
+	return Sessions(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := Session(r.Context())
+		switch r.URL.Path {
+		case "/login":
+			if err := s.Rotate(); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			s.Set("user_id", "u1")
+		case "/cart":
+			s.Set("cart", "apple")
+		case "/me":
+			user, _ := SessionValue[string](r.Context(), "user_id")
+			w.Write([]byte(user))
+			return
+		case "/logout":
+			s.Destroy()
+		case "/relogin":
+			s.Destroy()
+			s.Set("user_id", "u2")
+		}
+		w.Write([]byte(s.ID()))
+	}))
+}
+
+func TestSessions_Lifecycle(t *testing.T) {
+	store := newCountingSessionStore()
+	c := &sessionClient{t: t, handler: sessionTestHandler(SessionOptions{Store: store})}
+
+	// 没有访问会话的请求不会触碰存储，也不会下发 Cookie
+	w := c.do("/static")
+	assert.Empty(t, w.Result().Cookies())
+	assert.Zero(t, store.loads.Load()+store.saves.Load())
+
+	// 匿名会话
+	w = c.do("/cart")
+	require.NotNil(t, c.cookie)
+	assert.True(t, c.cookie.HttpOnly)
+	assert.True(t, c.cookie.Secure)
+	anonID := c.cookie.Value
+	assert.Equal(t, anonID, w.Body.String())
+
+	// 只读访问不会重复保存
+	saves := store.saves.Load()
+	assert.Empty(t, c.do("/me").Body.String())
+	assert.Equal(t, saves, store.saves.Load())
+
+	// 登录：轮换 ID，保留匿名阶段的数据，旧 ID 失效
+	w = c.do("/login")
+	require.NotNil(t, c.cookie)
+	assert.NotEqual(t, anonID, c.cookie.Value)
+	rec, _ := store.MemorySessionStore.Load(context.Background(), c.cookie.Value)
+	require.NotNil(t, rec)
+	assert.Equal(t, map[string]any{"cart": "apple", "user_id": "u1"}, rec.Values)
+	rec, _ = store.MemorySessionStore.Load(context.Background(), anonID)
+	assert.Nil(t, rec, "session fixation: the pre-login ID must be invalidated")
+	assert.Equal(t, "u1", c.do("/me").Body.String())
+
+	// 登出：删除会话并清除 Cookie
+	loggedIn := c.cookie.Value
+	c.do("/logout")
+	assert.Nil(t, c.cookie)
+	rec, _ = store.MemorySessionStore.Load(context.Background(), loggedIn)
+	assert.Nil(t, rec)
+	assert.Empty(t, c.do("/me").Body.String())
+
+	// 销毁后重新写入会开始一个新会话
+	c.do("/cart")
+	old := c.cookie.Value
+	w = c.do("/relogin")
+	require.NotNil(t, c.cookie)
+	assert.NotEqual(t, old, c.cookie.Value)
+	assert.Equal(t, "u2", c.do("/me").Body.String())
+}
+
+func TestSessions_Timeouts(t *testing.T) {
+	store := newCountingSessionStore()
+	opts := SessionOptions{Store: store, IdleTimeout: time.Hour, AbsoluteTimeout: 8 * time.Hour}
+	c := &sessionClient{t: t, handler: sessionTestHandler(opts)}
+
+	seed := func(created, accessed time.Time) {
+		id, err := newSessionID()
+		require.NoError(t, err)
+		rec := &SessionRecord{Values: map[string]any{"user_id": "u1"}, CreatedAt: created, AccessedAt: accessed}
+		require.NoError(t, store.MemorySessionStore.Save(context.Background(), id, rec, 24*time.Hour))
+		c.cookie = &http.Cookie{Name: "__Host-session_id", Value: id}
+	}
+	now := time.Now()
+
+	seed(now.Add(-time.Hour), now.Add(-time.Minute))
+	assert.Equal(t, "u1", c.do("/me").Body.String(), "active session")
+
+	seed(now.Add(-2*time.Hour), now.Add(-61*time.Minute))
+	id := c.cookie.Value
+	assert.Empty(t, c.do("/me").Body.String(), "idle timeout")
+	assert.Nil(t, c.cookie, "stale cookie is cleared")
+	rec, _ := store.MemorySessionStore.Load(context.Background(), id)
+	assert.Nil(t, rec, "stale session is deleted")
+
+	seed(now.Add(-9*time.Hour), now.Add(-time.Minute))
+	assert.Empty(t, c.do("/me").Body.String(), "absolute timeout")
+
+	// 空闲计时的刷新：超过 IdleTimeout/10 未保存时，只读访问也会保存一次
+	seed(now.Add(-time.Hour), now.Add(-10*time.Minute))
+	saves := store.saves.Load()
+	c.do("/me")
+	assert.Equal(t, saves+1, store.saves.Load())
+
+	// 会话的 TTL 不会超过绝对超时的剩余时间
+	seed(now.Add(-8*time.Hour+time.Minute), now.Add(-10*time.Minute))
+	c.do("/me")
+	e, ok := store.sessions.Load(c.cookie.Value)
+	require.True(t, ok)
+	assert.WithinDuration(t, now.Add(time.Minute), e.expires, 5*time.Second)
+}
+
+func TestSessions_CookieBeforeHeaders(t *testing.T) {
+	h := Sessions(SessionOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Session(r.Context()).Set("k", "v")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", nil))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Len(t, w.Result().Cookies(), 1)
+
+	// 通过 NewHandler 返回的响应同样在写出之前保存
+	api := Sessions(SessionOptions{})(NewHandler(func(ctx context.Context, req *struct{}) (*TestRes, error) {
+		Session(ctx).Set("k", "v")
+		return &TestRes{ID: "1"}, nil
+	}))
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, w.Result().Cookies(), 1)
+}
+
+func TestSessions_BadInput(t *testing.T) {
+	store := newCountingSessionStore()
+	h := sessionTestHandler(SessionOptions{Store: store})
+
+	// 格式无效的 Cookie 不会交给存储，并被清除
+	r := httptest.NewRequest("POST", "/me", nil)
+	r.AddCookie(&http.Cookie{Name: "session_id", Value: strings.Repeat("x", 10)})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Zero(t, store.loads.Load())
+	require.NotEmpty(t, w.Result().Cookies())
+	assert.Negative(t, w.Result().Cookies()[0].MaxAge)
+
+	// 存储故障：会话表现为空，且不会覆盖存储
+	store.loadErr = errors.New("redis down")
+	var reported error
+	ErrorHook = func(ctx context.Context, err error) { reported = err }
+	defer func() { ErrorHook = nil }()
+
+	id, _ := newSessionID()
+	var sessErr error
+	h = Sessions(SessionOptions{Store: store})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := Session(r.Context())
+		s.Set("k", "v")
+		sessErr = s.Err()
+	}))
+	r = httptest.NewRequest("POST", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "session_id", Value: id})
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.ErrorIs(t, sessErr, store.loadErr)
+	assert.ErrorIs(t, reported, store.loadErr)
+	assert.Zero(t, store.saves.Load())
+	assert.Empty(t, w.Result().Cookies())
+
+	assert.Nil(t, Session(context.Background()))
+	_, ok := SessionValue[string](context.Background(), "k")
+	assert.False(t, ok)
+}
+
+func TestSessions_CSRF(t *testing.T) {
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(CSRFToken(r.Context())))
+	}), Sessions(SessionOptions{}), CSRF(CSRFOptions{Store: SessionCSRFStore{}}))
+	c := &sessionClient{t: t, handler: h}
+
+	r := httptest.NewRequest("GET", "https://example.com/form", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	for _, ck := range w.Result().Cookies() {
+		c.cookie = ck
+	}
+	require.NotNil(t, c.cookie, "the token is kept in a new session")
+	token := w.Body.String()
+
+	post := func(token string) int {
+		r := httptest.NewRequest("POST", "https://example.com/transfer", nil)
+		r.AddCookie(c.cookie)
+		r.Header.Set("X-CSRF-Token", token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, post(token))
+	assert.Equal(t, http.StatusForbidden, post(maskCSRFToken(make([]byte, csrfTokenSize))))
+
+	// 没有 Sessions 中间件属于配置错误
+	w = httptest.NewRecorder()
+	CSRF(CSRFOptions{Store: SessionCSRFStore{}})(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestMemorySessionStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemorySessionStore()
+	rec := &SessionRecord{Values: map[string]any{"a": 1}}
+	require.NoError(t, s.Save(ctx, "short", rec, time.Millisecond))
+	require.NoError(t, s.Save(ctx, "long", rec, time.Hour))
+
+	// 返回的是副本
+	rec.Values["a"] = 2
+	got, err := s.Load(ctx, "long")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.Values["a"])
+	got.Values["a"] = 3
+	got, _ = s.Load(ctx, "long")
+	assert.Equal(t, 1, got.Values["a"])
+
+	time.Sleep(5 * time.Millisecond)
+	got, err = s.Load(ctx, "short")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	_, ok := s.sessions.Load("short")
+	assert.False(t, ok, "expired sessions are evicted")
+
+	require.NoError(t, s.Delete(ctx, "long"))
+	got, _ = s.Load(ctx, "long")
+	assert.Nil(t, got)
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestChain(t *testing.T) {
@@ -72,3 +73,87 @@ func TestRecovery(t *testing.T) {
 	assert.True(t, loggerCalled)
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
+
+func TestRecovery_PanicError(t *testing.T) {
+	var captured error
+	hook := func(ctx context.Context, err error) { captured = err }
+
+	t.Run("StackAndKind", func(t *testing.T) {
+		h := Recovery(WithHook(hook))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var m map[string]int
+			m["boom"] = 1 // nil map 写入触发 runtime.Error
+		}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		var perr *PanicError
+		require.ErrorAs(t, captured, &perr)
+		assert.Equal(t, PanicKindRuntime, perr.Kind)
+		assert.Contains(t, string(perr.StackTrace()), "TestRecovery_PanicError")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NotContains(t, w.Body.String(), "nil map")
+	})
+
+	t.Run("ErrorValueKeepsStatus", func(t *testing.T) {
+		h := Recovery(WithHook(hook))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(ErrNotFound)
+		}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		assert.ErrorIs(t, captured, ErrNotFound)
+		assert.Equal(t, PanicKindError, captured.(*PanicError).Kind)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), CodeNotFound)
+	})
+}
+
+func TestRecovery_AbortHandler(t *testing.T) {
+	hookCalled := false
+	h := Recovery(WithHook(func(ctx context.Context, err error) { hookCalled = true }))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}),
+	)
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+	assert.False(t, hookCalled)
+}
+
+func TestRecovery_HeadersAlreadyWritten(t *testing.T) {
+	var captured error
+	h := Recovery(WithHook(func(ctx context.Context, err error) { captured = err }))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("partial"))
+			panic("late failure")
+		}),
+	)
+
+	w := httptest.NewRecorder()
+	// 响应已经开始，只能中止连接，不能再写第二个状态码
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "partial", w.Body.String())
+	require.Error(t, captured)
+	assert.Equal(t, "panic: late failure", captured.Error())
+}
+
+func TestRecovery_PanicHookMetrics(t *testing.T) {
+	m := NewMetrics()
+	router := NewRouter()
+	router.HandleFunc("GET /explode/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("kaboom")
+	})
+
+	h := Chain(router, Recovery(WithPanicHook(m.PanicHook())))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/explode/1", nil))
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, w.Body.String(), `httpx_panics_total{route="GET /explode/{id}",kind="value"} 1`)
+}
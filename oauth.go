@@ -0,0 +1,150 @@
+package httpx
+
+import (
+	"net/http"
+
+	"github.com/bytedance/sonic"
+)
+
+// OAuthError 是 RFC 6749 §5.2 定义的错误响应。
+// 它实现了 json.Marshaler，因此 Error 会直接输出 {"error": ..., "error_description": ...}，不使用信封；
+// 同时实现 HeaderError，附带 Cache-Control: no-store 以及可选的 WWW-Authenticate。
+type OAuthError struct {
+	// Code 错误码，如 "invalid_client"、"invalid_grant"。
+	Code string
+	// Description 可读的错误描述 (error_description)。
+	Description string
+	// URI 错误说明页面 (error_uri)。
+	URI string
+	// Status HTTP 状态码。为 0 时按 Code 推断：invalid_client 为 401，
+	// server_error 为 500，temporarily_unavailable 为 503，其余为 400。
+	Status int
+	// Challenge 非空时写入 WWW-Authenticate。
+	// RFC 6749 要求客户端通过 Authorization 头认证失败时，返回与其认证方案一致的 Challenge。
+	Challenge string
+}
+
+// RFC 6749 §5.2 定义的 Token 端点错误
+var (
+	ErrOAuthInvalidRequest       = &OAuthError{Code: "invalid_request"}
+	ErrOAuthInvalidClient        = &OAuthError{Code: "invalid_client"}
+	ErrOAuthInvalidGrant         = &OAuthError{Code: "invalid_grant"}
+	ErrOAuthUnauthorizedClient   = &OAuthError{Code: "unauthorized_client"}
+	ErrOAuthUnsupportedGrantType = &OAuthError{Code: "unsupported_grant_type"}
+	ErrOAuthInvalidScope         = &OAuthError{Code: "invalid_scope"}
+	ErrOAuthServerError          = &OAuthError{Code: "server_error"}
+)
+
+// WithDescription 返回一个带有描述的副本，errors.Is 对原错误仍然成立。
+//
+//	return nil, httpx.ErrOAuthInvalidGrant.WithDescription("authorization code expired")
+func (e *OAuthError) WithDescription(desc string) *OAuthError {
+	c := *e
+	c.Description = desc
+	return &c
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+func (e *OAuthError) HTTPStatus() int {
+	if e.Status != 0 {
+		return e.Status
+	}
+	switch e.Code {
+	case "invalid_client":
+		return http.StatusUnauthorized
+	case "server_error":
+		return http.StatusInternalServerError
+	case "temporarily_unavailable":
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadRequest
+}
+
+func (e *OAuthError) BizStatus() string { return e.Code }
+
+func (e *OAuthError) PublicMessage() string { return e.Error() }
+
+// Is 按错误码比较，使 WithDescription 生成的副本与预定义错误相等。
+func (e *OAuthError) Is(target error) bool {
+	t, ok := target.(*OAuthError)
+	return ok && t.Code == e.Code
+}
+
+func (e *OAuthError) ResponseHeader() http.Header {
+	h := http.Header{
+		"Cache-Control": {"no-store"},
+		"Pragma":        {"no-cache"},
+	}
+	if e.Challenge != "" {
+		h["Www-Authenticate"] = []string{e.Challenge}
+	}
+	return h
+}
+
+func (e *OAuthError) MarshalJSON() ([]byte, error) {
+	return sonic.Marshal(struct {
+		Error       string `json:"error"`
+		Description string `json:"error_description,omitempty"`
+		URI         string `json:"error_uri,omitempty"`
+	}{e.Code, e.Description, e.URI})
+}
+
+// TokenResponse 是 RFC 6749 §5.1 的成功响应，实现了 Responder，配合 NewResponder 使用：
+// 以 200 直接输出 JSON (不使用信封)，并附带 Cache-Control: no-store 与 Pragma: no-cache。
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+
+	// Extra 额外的响应字段 (如 RFC 8693 的 issued_token_type)，不能覆盖标准字段。
+	Extra map[string]any `json:"-"`
+}
+
+func (t *TokenResponse) WriteResponse(w http.ResponseWriter, r *http.Request) {
+	var data []byte
+	var err error
+	if len(t.Extra) == 0 {
+		data, err = sonic.Marshal(t)
+	} else {
+		fields := make(map[string]any, len(t.Extra)+6)
+		for k, v := range t.Extra {
+			fields[k] = v
+		}
+		fields["access_token"] = t.AccessToken
+		fields["token_type"] = t.TokenType
+		if t.ExpiresIn != 0 {
+			fields["expires_in"] = t.ExpiresIn
+		}
+		if t.RefreshToken != "" {
+			fields["refresh_token"] = t.RefreshToken
+		}
+		if t.Scope != "" {
+			fields["scope"] = t.Scope
+		}
+		if t.IDToken != "" {
+			fields["id_token"] = t.IDToken
+		}
+		data, err = sonic.Marshal(fields)
+	}
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	h := w.Header()
+	// ⚡ Bolt: 直接写入 map，避免 Header.Set 的规范化开销
+	h["Content-Type"] = jsonContentType
+	h["Cache-Control"] = []string{"no-store"}
+	h["Pragma"] = []string{"no-cache"}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,106 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        *OAuthError
+		wantStatus int
+		wantBody   string
+		wantAuth   string
+	}{
+		{name: "InvalidRequest", err: ErrOAuthInvalidRequest, wantStatus: 400, wantBody: `{"error":"invalid_request"}`},
+		{
+			name:       "InvalidClientBasic",
+			err:        (&ClientCredentials{AuthMethod: ClientAuthSecretBasic}).InvalidClient("bad secret"),
+			wantStatus: 401,
+			wantBody:   `{"error":"invalid_client","error_description":"bad secret"}`,
+			wantAuth:   `Basic realm="oauth"`,
+		},
+		{name: "InvalidClientPost", err: (&ClientCredentials{AuthMethod: ClientAuthSecretPost}).InvalidClient(""), wantStatus: 401, wantBody: `{"error":"invalid_client"}`},
+		{name: "ServerError", err: ErrOAuthServerError, wantStatus: 500, wantBody: `{"error":"server_error"}`},
+		{name: "Unavailable", err: &OAuthError{Code: "temporarily_unavailable"}, wantStatus: 503, wantBody: `{"error":"temporarily_unavailable"}`},
+		{name: "Custom", err: &OAuthError{Code: "slow_down", URI: "https://example.com/e", Status: 429}, wantStatus: 429, wantBody: `{"error":"slow_down","error_uri":"https://example.com/e"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Error(w, httptest.NewRequest("POST", "/token", nil), tt.err)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.JSONEq(t, tt.wantBody, w.Body.String())
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			assert.Equal(t, "no-cache", w.Header().Get("Pragma"))
+			assert.Equal(t, tt.wantAuth, w.Header().Get("WWW-Authenticate"))
+		})
+	}
+
+	err := ErrOAuthInvalidGrant.WithDescription("code expired")
+	assert.ErrorIs(t, err, ErrOAuthInvalidGrant)
+	assert.NotErrorIs(t, err, ErrOAuthInvalidClient)
+	assert.Empty(t, ErrOAuthInvalidGrant.Description, "WithDescription must not mutate the shared error")
+	assert.Equal(t, "invalid_grant: code expired", err.Error())
+}
+
+type tokenReq struct {
+	ClientCredentials
+	GrantType string `form:"grant_type" validate:"required"`
+	Code      string `form:"code"`
+}
+
+func TestTokenEndpoint(t *testing.T) {
+	h := NewResponder(func(ctx context.Context, req *tokenReq) (*TokenResponse, error) {
+		if req.ClientID != "app" || req.ClientSecret != "s3cret" {
+			return nil, req.InvalidClient("unknown client")
+		}
+		if req.GrantType != "authorization_code" {
+			return nil, ErrOAuthUnsupportedGrantType
+		}
+		return &TokenResponse{
+			AccessToken: "at",
+			TokenType:   "Bearer",
+			ExpiresIn:   3600,
+			Extra:       map[string]any{"issued_token_type": "urn:ietf:params:oauth:token-type:access_token", "access_token": "forged"},
+		}, nil
+	}, WithBinders(&FormBinder{}, &ClientAuthBinder{}))
+
+	serve := func(form url.Values, user, pass string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/token", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if user != "" {
+			r.SetBasicAuth(user, pass)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(url.Values{"grant_type": {"authorization_code"}, "code": {"c"}}, "app", "s3cret")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"access_token":"at","token_type":"Bearer","expires_in":3600,"issued_token_type":"urn:ietf:params:oauth:token-type:access_token"}`, w.Body.String())
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	w = serve(url.Values{"grant_type": {"authorization_code"}}, "app", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Basic realm="oauth"`, w.Header().Get("WWW-Authenticate"))
+
+	w = serve(url.Values{"grant_type": {"password"}, "client_id": {"app"}, "client_secret": {"s3cret"}}, "", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"unsupported_grant_type"}`, w.Body.String())
+
+	// Binder 返回的 OAuthError 不会被包装成通用的 400
+	w = serve(url.Values{"grant_type": {"password"}, "client_secret": {"x"}}, "app", "s3cret")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"invalid_request","error_description":"multiple client authentication methods"}`, w.Body.String())
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
@@ -14,6 +14,9 @@ type config struct {
 	errorHook   func(ctx context.Context, err error)
 	maxBodySize int64
 	noVarySearch []string
+	routeName    string
+	deprecation  *deprecation
+	policies     []Policy
 }
 
 type Option func(*config)
@@ -118,3 +121,27 @@ func WithNoVarySearch(keys ...string) Option {
 		}
 	}
 }
+
+// RouteName 为路由命名，仅在通过 Register/RegisterStream/RegisterResponder 注册时生效，
+// 之后可以用 Router.URL 或 URLFor 反向生成 URL。
+func RouteName(name string) Option {
+	return func(c *config) {
+		c.routeName = name
+	}
+}
+
+// WithDeprecation 将该 Handler 标记为废弃，效果与 Deprecate 中间件相同：
+// 输出 Deprecation/Sunset/Link 响应头，并可在下线后返回 410 (ErrGone)。
+func WithDeprecation(opts DeprecationOptions) Option {
+	return func(c *config) {
+		c.deprecation = newDeprecation(opts)
+	}
+}
+
+// WithPolicy 为 Handler 添加授权策略，在绑定与验证之后、业务逻辑之前执行，
+// 因此可以使用 ResourcePolicy 基于请求内容做资源级检查。多次调用时要求全部通过。
+func WithPolicy(policies ...Policy) Option {
+	return func(c *config) {
+		c.policies = append(c.policies, policies...)
+	}
+}
@@ -0,0 +1,78 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+)
+
+// requestState 是在外层中间件与内层处理器之间共享的请求级可变状态。
+// 中间件通常通过 r.WithContext 复制请求，内层写入的 r.Pattern 等信息
+// 对外层不可见，因此需要一个通过 Context 传递的指针作为“回传通道”。
+type requestState struct {
+	// pattern 是 ServeMux 最终匹配到的路由模式
+	pattern string
+	// bizCode 是响应体中写出的业务码
+	bizCode string
+	// identity 是认证中间件识别出的身份
+	identity any
+	// clientIP 是 NewClientIPMiddleware 解析出的真实客户端 IP
+	clientIP string
+}
+
+type requestStateKey struct{}
+
+// withRequestState 确保 Context 中存在 requestState。
+// 如果外层已经注入过，则直接复用，保证多个观测类中间件看到同一份数据。
+func withRequestState(r *http.Request) (*http.Request, *requestState) {
+	if s, ok := r.Context().Value(requestStateKey{}).(*requestState); ok {
+		return r, s
+	}
+	s := &requestState{}
+	return r.WithContext(context.WithValue(r.Context(), requestStateKey{}, s)), s
+}
+
+func getRequestState(ctx context.Context) *requestState {
+	s, _ := ctx.Value(requestStateKey{}).(*requestState)
+	return s
+}
+
+// recordBizCode 在存在观测中间件时记录本次响应的业务码。
+func recordBizCode(ctx context.Context, code string) {
+	if s := getRequestState(ctx); s != nil {
+		s.bizCode = code
+	}
+}
+
+// recordIdentity 在存在观测中间件时记录认证得到的身份。
+func recordIdentity(ctx context.Context, identity any) {
+	if s := getRequestState(ctx); s != nil {
+		s.identity = identity
+	}
+}
+
+// recordClientIP 在存在观测中间件时记录客户端 IP。
+func recordClientIP(ctx context.Context, ip string) {
+	if s := getRequestState(ctx); s != nil {
+		s.clientIP = ip
+	}
+}
+
+// recordPattern 记录路由模式。
+// 嵌套 Router 时内层先返回，因此只保留第一次记录，即最具体的匹配结果。
+func recordPattern(r *http.Request) {
+	if r.Pattern == "" {
+		return
+	}
+	if s := getRequestState(r.Context()); s != nil && s.pattern == "" {
+		s.pattern = r.Pattern
+	}
+}
+
+// routeOf 返回请求最终匹配的路由模式。
+// 优先使用 Router 回传的结果，其次是 ServeMux 直接写在请求上的 r.Pattern。
+func routeOf(r *http.Request, s *requestState) string {
+	if s != nil && s.pattern != "" {
+		return s.pattern
+	}
+	return r.Pattern
+}
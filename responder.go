@@ -41,6 +41,9 @@ func NewResponder[Req any, Res Responder](fn HandlerFunc[Req, Res], opts ...Opti
 			return
 		}
 
+		// 先记录成功，WriteResponse 内部调用 Error 时会覆盖为对应的业务码
+		recordBizCode(r.Context(), CodeOK)
+
 		// 执行业务自定义的写入逻辑
 		// 注意：prepare 内部已经完成了 TraceID 在 Header 中的注入
 		res.WriteResponse(w, r)
@@ -1,20 +1,35 @@
 package httpx
 
 import (
+	"maps"
 	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // Router wraps http.ServeMux to provide grouping and middleware capabilities.
 type Router struct {
 	mux         *http.ServeMux
 	middlewares []func(http.Handler) http.Handler
+
+	// core is shared by every Router derived from the same NewRouter call (via With/Group).
+	core *routerCore
+	// method, host and prefix are the defaults inherited from a method-qualified Group pattern.
+	method string
+	host   string
+	prefix string
+	// version is set on the routers returned by VersionSet.Version.
+	version string
 }
 
 // NewRouter creates a new Router instance.
 func NewRouter() *Router {
 	return &Router{
-		mux: http.NewServeMux(),
+		mux:  http.NewServeMux(),
+		core: &routerCore{},
 	}
 }
 
@@ -28,49 +43,356 @@ func (r *Router) With(middleware ...func(http.Handler) http.Handler) *Router {
 	return &Router{
 		mux:         r.mux,
 		middlewares: mws,
+		core:        r.core,
+		method:      r.method,
+		host:        r.host,
+		prefix:      r.prefix,
+		version:     r.version,
 	}
 }
 
-// Group creates a sub-router mounted at the specified pattern configuration.
-// The pattern should be a path prefix like "/api/v1".
-// Accessing the sub-router via the pattern will strip the pattern prefix.
+// Use appends middleware to the router.
+// It only applies to handlers registered after the call; routers already derived
+// via With or Group are not affected.
+func (r *Router) Use(middleware ...func(http.Handler) http.Handler) {
+	// Force a copy so that routers sharing the backing array are not affected.
+	r.middlewares = append(r.middlewares[:len(r.middlewares):len(r.middlewares)], middleware...)
+}
+
+// Group creates a sub-router for the given prefix pattern.
+// The pattern may be a plain path prefix like "/api/v1", or be qualified with
+// a method and/or host like "GET /reports" or "api.example.com/v2".
+// Routes registered on the sub-router are registered on the same ServeMux with
+// the full pattern (prefix + sub-pattern), so wildcards declared in the prefix are
+// visible to r.PathValue. A method declared by the group applies to sub-patterns
+// that don't declare one; declaring a different method panics.
 func (r *Router) Group(pattern string, middleware ...func(http.Handler) http.Handler) *Router {
-	// Normalize pattern to be a path prefix
-	// If pattern is "POST /api", we probably just want "/api" for the mount?
-	// But Group usually implies path prefix.
+	method, host, path := splitPattern(pattern)
 
-	// Remove trailing slash for consistency in stripping
-	prefix := strings.TrimSuffix(pattern, "/")
-	// Mount needs trailing slash to match subtree
-	mountPattern := prefix + "/"
+	sub := r.With(middleware...)
+	sub.method = mergePatternPart("method", r.method, method)
+	sub.host = mergePatternPart("host", r.host, host)
+	sub.prefix = r.prefix + strings.TrimSuffix(path, "/")
+	return sub
+}
 
-	subRouter := NewRouter()
-	subRouter.middlewares = middleware
+// Mount attaches a foreign http.Handler (e.g. a third-party mux or file server)
+// under the given prefix. The handler sees the request path with the prefix stripped.
+// The prefix may be method- or host-qualified, like Group.
+func (r *Router) Mount(prefix string, handler http.Handler) {
+	method, host, path := splitPattern(prefix)
+	path = strings.TrimSuffix(path, "/")
+	full := r.prefix + path
 
-	// Register with StripPrefix
-	// Use r.Handle so that parent middlewares are applied to this group
-	r.Handle(mountPattern, http.StripPrefix(prefix, subRouter))
+	// Wildcards can't be stripped literally, strip as many segments as the prefix has instead.
+	if strings.Contains(full, "{") {
+		handler = stripSegments(strings.Count(full, "/"), handler)
+	} else {
+		handler = http.StripPrefix(full, handler)
+	}
 
-	return subRouter
+	// The subtree pattern "prefix/" also redirects "prefix" to "prefix/".
+	r.register(joinPattern(method, host, path+"/"), handler, RouteInfo{})
+}
+
+// stripSegments is like http.StripPrefix, but strips the first n segments of the path,
+// so that prefixes declaring wildcards (e.g. "/tenants/{tenant}") can be mounted.
+func stripSegments(n int, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Work on the escaped path so that "%2F" inside a segment is not counted as a separator.
+		rp := trimSegments(r.URL.EscapedPath(), n)
+		p, err := url.PathUnescape(rp)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = p
+		if r.URL.RawPath != "" {
+			r2.URL.RawPath = rp
+		}
+		h.ServeHTTP(w, r2)
+	})
+}
+
+// trimSegments removes the first n "/segment" parts of p. The result keeps its leading slash.
+func trimSegments(p string, n int) string {
+	for range n {
+		if p == "" {
+			break
+		}
+		i := strings.IndexByte(p[1:], '/')
+		if i < 0 {
+			return ""
+		}
+		p = p[i+1:]
+	}
+	return p
+}
+
+// Host returns a sub-router whose routes only match requests for the given host,
+// e.g. "api.example.com" or "tenant.example.com/v2". It is a shorthand for Group(host, middleware...).
+func (r *Router) Host(host string, middleware ...func(http.Handler) http.Handler) *Router {
+	if strings.ContainsAny(host, " \t") || strings.HasPrefix(host, "/") {
+		panic("httpx: bad host pattern " + strconv.Quote(host))
+	}
+	return r.Group(host, middleware...)
 }
 
 // Handle registers the handler for the given pattern.
 // It applies the router's middleware chain to the handler.
 func (r *Router) Handle(pattern string, handler http.Handler) {
+	r.register(pattern, handler, RouteInfo{})
+}
+
+// HandleNamed registers the handler for the given pattern under a name,
+// so that its URL can be built with URL or URLFor. Names must be unique within the router tree.
+func (r *Router) HandleNamed(name, pattern string, handler http.Handler) {
+	r.register(pattern, handler, RouteInfo{Name: name})
+}
+
+// HandleFunc registers the handler function for the given pattern.
+func (r *Router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	r.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// register records the route and registers it on the ServeMux with the full pattern.
+// info carries the optional Req/Res types of typed handlers.
+func (r *Router) register(pattern string, handler http.Handler, info RouteInfo) {
+	method, host, path := splitPattern(pattern)
+	info.Method = mergePatternPart("method", r.method, method)
+	info.Host = mergePatternPart("host", r.host, host)
+	info.Path = r.prefix + path
+	info.Pattern = joinPattern(info.Method, info.Host, info.Path)
+	info.Middlewares = middlewareNames(r.middlewares)
+	info.Version = r.version
+	if info.ReqType != nil {
+		info.Request = info.ReqType.String()
+	}
+	if info.ResType != nil {
+		info.Response = info.ResType.String()
+	}
+
+	// Claim the name before registering anything, and release it if the ServeMux
+	// rejects the pattern, so a panic never leaves a half-registered route behind.
+	if info.Name != "" {
+		r.core.addName(info)
+		defer func() {
+			if p := recover(); p != nil {
+				r.core.removeName(info.Name)
+				panic(p)
+			}
+		}()
+	}
+	r.handle(info.Pattern, handler)
+	r.core.addRoute(info)
+}
+
+// NotFound sets the handler for requests matching no route.
+// By default they are rendered through Error with ErrNotFound.
+// It applies to the whole router tree (including groups).
+func (r *Router) NotFound(h http.Handler) {
+	r.core.mu.Lock()
+	r.core.notFound = h
+	r.core.mu.Unlock()
+}
+
+// MethodNotAllowed sets the handler for requests matching a route only by path.
+// The Allow header is already set when h is called.
+// By default they are rendered through Error with a *MethodNotAllowedError.
+// It applies to the whole router tree (including groups).
+func (r *Router) MethodNotAllowed(h http.Handler) {
+	r.core.mu.Lock()
+	r.core.methodNotAllowed = h
+	r.core.mu.Unlock()
+}
+
+// handle applies the middleware chain and registers the full pattern on the ServeMux without recording it.
+func (r *Router) handle(pattern string, handler http.Handler) {
 	// Apply middlewares in reverse order (Chain behavior: m1(m2(h)))
 	final := handler
 	for i := len(r.middlewares) - 1; i >= 0; i-- {
 		final = r.middlewares[i](final)
 	}
-	r.mux.Handle(pattern, final)
+	r.mux.Handle(pattern, routeHandler{final})
 }
 
-// HandleFunc registers the handler function for the given pattern.
-func (r *Router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
-	r.Handle(pattern, http.HandlerFunc(handler))
+// routeHandler wraps every registered route. Matched routes are handed the original
+// ResponseWriter back, so only the ServeMux's own fallback handlers write to the fallbackWriter.
+type routeHandler struct {
+	h http.Handler
+}
+
+func (h routeHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if fw, ok := w.(*fallbackWriter); ok {
+		fw.matched = true
+		w = fw.w
+	}
+	h.h.ServeHTTP(w, req)
 }
 
 // ServeHTTP satisfies http.Handler.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	r.mux.ServeHTTP(w, req)
+	// The request is routed once: matched routes unwrap the fallbackWriter and write to w directly,
+	// and only the responses of the ServeMux fallbacks (404/405, redirects to cleaned paths) are buffered.
+	fw := fallbackWriterPool.Get().(*fallbackWriter)
+	fw.w = w
+	// Deferred so that a panicking route still reports its pattern to Recovery/Metrics outside the router
+	defer func() {
+		fw.reset()
+		fallbackWriterPool.Put(fw)
+		recordPattern(req)
+	}()
+	r.mux.ServeHTTP(fw, req)
+	if !fw.matched {
+		r.serveFallback(w, req, fw)
+	}
+}
+
+// serveFallback renders the 404/405 responses of the ServeMux through the router's handlers,
+// and replays any other fallback response (e.g. a redirect) as is.
+func (r *Router) serveFallback(w http.ResponseWriter, req *http.Request, fw *fallbackWriter) {
+	r.core.mu.RLock()
+	notFound, methodNotAllowed := r.core.notFound, r.core.methodNotAllowed
+	r.core.mu.RUnlock()
+
+	switch fw.status {
+	case http.StatusNotFound:
+		if notFound != nil {
+			notFound.ServeHTTP(w, req)
+			return
+		}
+		Error(w, req, ErrNotFound)
+	case http.StatusMethodNotAllowed:
+		allow := fw.header.Values("Allow")
+		if methodNotAllowed != nil {
+			w.Header()["Allow"] = allow
+			methodNotAllowed.ServeHTTP(w, req)
+			return
+		}
+		var methods []string
+		for _, v := range allow {
+			for m := range strings.SplitSeq(v, ",") {
+				methods = append(methods, strings.TrimSpace(m))
+			}
+		}
+		Error(w, req, &MethodNotAllowedError{Allow: methods})
+	default:
+		maps.Copy(w.Header(), fw.header)
+		if fw.status != 0 {
+			w.WriteHeader(fw.status)
+		}
+		w.Write(fw.body)
+	}
+}
+
+// fallbackWriter buffers the small responses written by the ServeMux fallback handlers.
+type fallbackWriter struct {
+	w       http.ResponseWriter
+	matched bool
+	header  http.Header
+	status  int
+	body    []byte
+}
+
+var fallbackWriterPool = sync.Pool{New: func() any { return new(fallbackWriter) }}
+
+func (f *fallbackWriter) Header() http.Header {
+	if f.header == nil {
+		f.header = make(http.Header)
+	}
+	return f.header
+}
+
+func (f *fallbackWriter) Write(b []byte) (int, error) {
+	if f.status == 0 {
+		f.status = http.StatusOK
+	}
+	f.body = append(f.body, b...)
+	return len(b), nil
+}
+
+func (f *fallbackWriter) WriteHeader(code int) {
+	if f.status == 0 {
+		f.status = code
+	}
+}
+
+func (f *fallbackWriter) reset() {
+	*f = fallbackWriter{}
+}
+
+// ---------------------------------------------------------------------------
+// Typed registration
+// ---------------------------------------------------------------------------
+
+// Register registers a typed handler built by NewHandler and records its Req/Res types,
+// so that they show up in Routes() and the route table.
+func Register[Req any, Res any](r *Router, pattern string, fn HandlerFunc[Req, Res], opts ...Option) {
+	r.register(pattern, NewHandler(fn, opts...), typedRouteInfo[Req, Res](opts))
+}
+
+// RegisterStream is the NewStreamHandler counterpart of Register.
+func RegisterStream[Req any, Res Streamable](r *Router, pattern string, fn HandlerFunc[Req, Res], opts ...Option) {
+	r.register(pattern, NewStreamHandler(fn, opts...), typedRouteInfo[Req, Res](opts))
+}
+
+// RegisterResponder is the NewResponder counterpart of Register.
+func RegisterResponder[Req any, Res Responder](r *Router, pattern string, fn HandlerFunc[Req, Res], opts ...Option) {
+	r.register(pattern, NewResponder(fn, opts...), typedRouteInfo[Req, Res](opts))
+}
+
+func typedRouteInfo[Req any, Res any](opts []Option) RouteInfo {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return RouteInfo{
+		Name:    cfg.routeName,
+		ReqType: reflect.TypeFor[Req](),
+		ResType: reflect.TypeFor[Res](),
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Pattern helpers
+// ---------------------------------------------------------------------------
+
+// splitPattern splits a ServeMux pattern "[METHOD ][HOST]/[PATH]" into its parts.
+func splitPattern(pattern string) (method, host, path string) {
+	rest := strings.TrimSpace(pattern)
+	if i := strings.IndexAny(rest, " \t"); i >= 0 {
+		method = rest[:i]
+		rest = strings.TrimLeft(rest[i:], " \t")
+	}
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		host, path = rest[:i], rest[i:]
+	} else {
+		host = rest
+	}
+	return method, host, path
+}
+
+// mergePatternPart combines the method or host declared by a group with the one
+// declared by a sub-pattern. Conflicting values are a programming error.
+func mergePatternPart(kind, group, sub string) string {
+	if group == "" {
+		return sub
+	}
+	if sub != "" && sub != group {
+		panic("httpx: " + kind + " " + strconv.Quote(sub) + " conflicts with group " + kind + " " + strconv.Quote(group))
+	}
+	return group
+}
+
+// joinPattern is the inverse of splitPattern.
+func joinPattern(method, host, path string) string {
+	if method == "" {
+		return host + path
+	}
+	return method + " " + host + path
 }
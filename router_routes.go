@@ -0,0 +1,172 @@
+package httpx
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/bytedance/sonic"
+)
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	// Name is the name given by HandleNamed or the RouteName option, if any.
+	Name string `json:"name,omitempty"`
+	// Method is the HTTP method of the pattern, empty if the route matches any method.
+	Method string `json:"method,omitempty"`
+	// Host is the host part of the pattern, if any.
+	Host string `json:"host,omitempty"`
+	// Path is the full path, including the prefixes applied by Group.
+	Path string `json:"path"`
+	// Pattern is the full ServeMux pattern, e.g. "GET /api/v1/users/{id}".
+	Pattern string `json:"pattern"`
+	// Version is the API version of routes registered through VersionSet.Version.
+	Version string `json:"version,omitempty"`
+	// Middlewares lists the names of the middlewares wrapping the handler, outermost first.
+	Middlewares []string `json:"middlewares,omitempty"`
+	// Request and Response are the Req/Res type names of typed handlers.
+	Request  string `json:"request,omitempty"`
+	Response string `json:"response,omitempty"`
+
+	// ReqType and ResType are the Req/Res types of typed handlers (see Register).
+	ReqType reflect.Type `json:"-"`
+	ResType reflect.Type `json:"-"`
+}
+
+// routerCore holds the state shared by all Routers derived from one NewRouter call.
+type routerCore struct {
+	mu     sync.RWMutex
+	routes []RouteInfo
+	names  map[string]RouteInfo
+
+	notFound         http.Handler
+	methodNotAllowed http.Handler
+}
+
+func (c *routerCore) addRoute(info RouteInfo) {
+	c.mu.Lock()
+	c.routes = append(c.routes, info)
+	c.mu.Unlock()
+}
+
+func (c *routerCore) addName(info RouteInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prev, ok := c.names[info.Name]; ok {
+		panic("httpx: route name " + strconv.Quote(info.Name) + " already used by " + strconv.Quote(prev.Pattern))
+	}
+	if c.names == nil {
+		c.names = make(map[string]RouteInfo)
+	}
+	c.names[info.Name] = info
+}
+
+func (c *routerCore) removeName(name string) {
+	c.mu.Lock()
+	delete(c.names, name)
+	c.mu.Unlock()
+}
+
+func (c *routerCore) route(name string) (RouteInfo, bool) {
+	c.mu.RLock()
+	info, ok := c.names[name]
+	c.mu.RUnlock()
+	return info, ok
+}
+
+// Routes returns every route registered on the router tree (including groups and versions),
+// sorted by path, method and version.
+func (r *Router) Routes() []RouteInfo {
+	r.core.mu.RLock()
+	routes := slices.Clone(r.core.routes)
+	r.core.mu.RUnlock()
+
+	slices.SortStableFunc(routes, func(a, b RouteInfo) int {
+		if c := strings.Compare(a.Host+a.Path, b.Host+b.Path); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Method, b.Method); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Version, b.Version)
+	})
+	return routes
+}
+
+// RoutesHandler returns a debug handler rendering the route table.
+// It responds with JSON by default, and with a plain-text table when
+// the query contains "format=text" or the client only accepts text/plain.
+//
+// It exposes the internal structure of the API, so mount it behind authentication
+// or on an internal listener only.
+func (r *Router) RoutesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		routes := r.Routes()
+
+		if req.URL.Query().Get("format") == "text" || strings.HasPrefix(req.Header.Get("Accept"), "text/plain") {
+			w.Header()["Content-Type"] = []string{"text/plain; charset=utf-8"}
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "METHOD\tPATTERN\tREQUEST\tRESPONSE\tMIDDLEWARES")
+			for _, rt := range routes {
+				method := rt.Method
+				if method == "" {
+					method = "*"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", method, rt.Host+rt.Path, dash(rt.Request), dash(rt.Response), dash(strings.Join(rt.Middlewares, ", ")))
+			}
+			_ = tw.Flush()
+			return
+		}
+
+		data, err := sonic.ConfigDefault.Marshal(routes)
+		if err != nil {
+			Error(w, req, err)
+			return
+		}
+		w.Header()["Content-Type"] = jsonContentType
+		_, _ = w.Write(data)
+		_, _ = w.Write(nlBytes)
+	})
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// funcSuffix matches the suffixes the compiler appends to closures and method values.
+var funcSuffix = regexp.MustCompile(`(\.func\d+)+$|-fm$`)
+
+// middlewareNames derives readable names from middleware functions,
+// e.g. "github.com/oy3o/httpx.Recovery.func1" becomes "httpx.Recovery".
+func middlewareNames(mws []func(http.Handler) http.Handler) []string {
+	if len(mws) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(mws))
+	for _, mw := range mws {
+		names = append(names, funcName(mw))
+	}
+	return names
+}
+
+func funcName(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "unknown"
+	}
+	name := f.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return funcSuffix.ReplaceAllString(name, "")
+}
@@ -0,0 +1,82 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Routes(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {})
+
+	api := router.Group("/api/v1", SecurityHeaders())
+	Register(api, "GET /users/{id}", func(ctx context.Context, req *TestReqQuery) (*TestRes, error) {
+		return &TestRes{}, nil
+	})
+	admin := api.Group("/admin").With(RateLimit(&mockLimiter{allowed: true}))
+	admin.HandleFunc("DELETE /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	routes := router.Routes()
+	require.Len(t, routes, 3)
+
+	assert.Equal(t, "DELETE /api/v1/admin/users/{id}", routes[0].Pattern)
+	assert.Equal(t, []string{"httpx.SecurityHeaders", "httpx.RateLimit"}, routes[0].Middlewares)
+
+	assert.Equal(t, "GET", routes[1].Method)
+	assert.Equal(t, "/api/v1/users/{id}", routes[1].Path)
+	assert.Equal(t, "httpx.TestReqQuery", routes[1].Request)
+	assert.Equal(t, "*httpx.TestRes", routes[1].Response)
+	assert.Equal(t, reflect.TypeFor[TestReqQuery](), routes[1].ReqType)
+
+	assert.Equal(t, "GET /health", routes[2].Pattern)
+	assert.Empty(t, routes[2].Middlewares)
+
+	// 所有派生 Router 共享同一张路由表
+	assert.Equal(t, routes, api.Routes())
+
+	// 类型化注册的路由仍然可以正常访问
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/users/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRouter_RoutesHandler(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("/static/", func(w http.ResponseWriter, r *http.Request) {})
+	Register(router, "POST /login", func(ctx context.Context, req *TestReqReflect) (*TestRes, error) {
+		return nil, nil
+	})
+	router.Handle("GET /debug/routes", router.RoutesHandler())
+
+	t.Run("JSON", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/debug/routes", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var routes []RouteInfo
+		require.NoError(t, sonic.Unmarshal(w.Body.Bytes(), &routes))
+		require.Len(t, routes, 3)
+		assert.Equal(t, "GET /debug/routes", routes[0].Pattern)
+		assert.Equal(t, "POST /login", routes[1].Pattern)
+		assert.Equal(t, "httpx.TestReqReflect", routes[1].Request)
+		assert.Equal(t, "/static/", routes[2].Pattern)
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/debug/routes?format=text", nil))
+		body := w.Body.String()
+
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+		assert.Contains(t, body, "METHOD")
+		assert.Regexp(t, `POST\s+/login\s+httpx.TestReqReflect\s+\*httpx.TestRes`, body)
+		assert.Regexp(t, `\*\s+/static/\s+-`, body)
+	})
+}
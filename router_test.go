@@ -1,12 +1,14 @@
 package httpx
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRouter_Handle(t *testing.T) {
@@ -134,9 +136,8 @@ func TestRouter_Group_WithPatternVariables(t *testing.T) {
 	router := NewRouter()
 	api := router.Group("/api")
 
-	// When using strip prefix (Group behavior), the pattern matching for path values
-	// applies to the remaining path in the SUB-router.
-	// E.g. /api/users/123 -> subrouter sees /users/123
+	// Group registers the full pattern (prefix + sub-pattern) on the shared ServeMux,
+	// e.g. "GET /users/{id}" becomes "GET /api/users/{id}".
 
 	api.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
 		id := r.PathValue("id")
@@ -170,3 +171,193 @@ func TestRouter_Handle_MethodMatching(t *testing.T) {
 	assert.Equal(t, http.StatusOK, wPost.Code)
 	assert.Equal(t, "Submitted", wPost.Body.String())
 }
+
+func TestRouter_Use(t *testing.T) {
+	router := NewRouter()
+	mw := func(tag string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Chain", tag)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	router.HandleFunc("GET /before", ok)
+	router.Use(mw("global"))
+	api := router.Group("/api")
+	api.Use(mw("api"))
+	api.HandleFunc("GET /users", ok)
+	router.HandleFunc("GET /after", ok)
+
+	tests := []struct {
+		path  string
+		chain []string
+	}{
+		{"/before", nil},
+		{"/after", []string{"global"}},
+		{"/api/users", []string{"global", "api"}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, tt.path)
+		assert.Equal(t, tt.chain, w.Header().Values("X-Chain"), tt.path)
+	}
+}
+
+func TestRouter_Mount(t *testing.T) {
+	router := NewRouter()
+	foreign := http.NewServeMux()
+	foreign.HandleFunc("GET /files/{name}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s:%s", r.URL.Path, r.PathValue("name"))
+	})
+	router.Group("/static").Mount("/v1", foreign)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/static/v1/files/a.txt", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/files/a.txt:a.txt", w.Body.String())
+
+	// 不带末尾斜杠时由 ServeMux 重定向
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/static/v1", nil))
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+
+	assert.Equal(t, "/static/v1/", router.Routes()[0].Pattern)
+}
+
+func TestRouter_Group_MethodQualified(t *testing.T) {
+	router := NewRouter()
+	reports := router.Group("GET /reports")
+	reports.HandleFunc("/daily", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("daily"))
+	})
+	reports.HandleFunc("GET /weekly", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("weekly"))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/reports/daily", nil))
+	assert.Equal(t, "daily", w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/reports/daily", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/reports/weekly", nil))
+	assert.Equal(t, "weekly", w.Body.String())
+
+	assert.PanicsWithValue(t, `httpx: method "POST" conflicts with group method "GET"`, func() {
+		reports.HandleFunc("POST /rebuild", func(w http.ResponseWriter, r *http.Request) {})
+	})
+}
+
+func TestRouter_Group_PrefixWildcards(t *testing.T) {
+	type issueReq struct {
+		Tenant  string `path:"tenant"`
+		Project string `path:"project"`
+		ID      int    `path:"id"`
+	}
+
+	router := NewRouter()
+	tenant := router.Group("/tenants/{tenant}")
+	tenant.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "tenant=%s", r.PathValue("tenant"))
+	})
+	project := tenant.Group("/projects/{project}")
+	Register(project, "GET /issues/{id}", func(ctx context.Context, req *issueReq) (*issueReq, error) {
+		return req, nil
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/tenants/acme/", nil))
+	assert.Equal(t, "tenant=acme", w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/tenants/acme/projects/httpx/issues/42", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"code":"OK","message":"success","data":{"Tenant":"acme","Project":"httpx","ID":42}}`, w.Body.String())
+
+	assert.Equal(t, "GET /tenants/{tenant}/projects/{project}/issues/{id}", router.Routes()[1].Pattern)
+}
+
+func TestRouter_Mount_PrefixWildcards(t *testing.T) {
+	router := NewRouter()
+	router.Group("/tenants/{tenant}").Mount("/files", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s", r.URL.Path, r.URL.RawPath)
+	}))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/tenants/acme/files/docs/a.txt", nil))
+	assert.Equal(t, "/docs/a.txt|", w.Body.String())
+
+	// 段内的 %2F 不视为分隔符
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/tenants/a%2Fb/files/x%2Fy", nil))
+	assert.Equal(t, "/x/y|/x%2Fy", w.Body.String())
+}
+
+func TestRouter_NotFoundAndMethodNotAllowed(t *testing.T) {
+	router := NewRouter()
+	router.HandleFunc("GET /items", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("POST /items", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) {})
+
+	t.Run("DefaultNotFound", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"code":"NOT_FOUND","message":"Not Found"}`, w.Body.String())
+	})
+
+	t.Run("DefaultMethodNotAllowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("DELETE", "/items", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, HEAD, POST", w.Header().Get("Allow"))
+		assert.JSONEq(t, `{"code":"METHOD_NOT_ALLOWED","message":"Method Not Allowed"}`, w.Body.String())
+	})
+
+	t.Run("RedirectPassesThrough", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/files", nil))
+		assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		router.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}))
+		router.Group("/api").MethodNotAllowed(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "allow: ", w.Header().Get("Allow"))
+		}))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+		assert.Equal(t, http.StatusTeapot, w.Code)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("PUT", "/items", nil))
+		assert.Equal(t, "allow: GET, HEAD, POST", w.Body.String())
+	})
+}
+
+func TestRouter_MatchedRouteGetsOriginalWriter(t *testing.T) {
+	router := NewRouter()
+	var got http.ResponseWriter
+	router.Group("/api").HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		got = w
+		w.WriteHeader(http.StatusNotFound) // 路由自己返回的 404 不会被当作未匹配
+		w.Write([]byte(r.PathValue("id")))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/items/7", nil))
+	assert.Same(t, w, got)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "7", w.Body.String())
+}
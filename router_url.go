@@ -0,0 +1,108 @@
+package httpx
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/oy3o/httpx/internal/urlenc"
+)
+
+// URL builds the URL path of the route registered under name (see HandleNamed and RouteName).
+// params are key/value pairs: keys naming a wildcard of the pattern fill it, the others
+// are appended as query parameters. Values are escaped; a {rest...} wildcard keeps
+// its slashes and escapes each segment.
+//
+//	r.HandleNamed("file", "GET /users/{id}/files/{path...}", h)
+//	r.URL("file", "id", "42", "path", "a b/c.txt", "v", "2") // "/users/42/files/a%20b/c.txt?v=2"
+//
+// Only the path is returned, even if the pattern declares a host.
+func (r *Router) URL(name string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("httpx: odd number of URL params for route %q", name)
+	}
+	info, ok := r.core.route(name)
+	if !ok {
+		return "", fmt.Errorf("httpx: unknown route %q", name)
+	}
+
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+	path, used, err := urlenc.ExpandPath(info.Path, values)
+	if err != nil {
+		return "", fmt.Errorf("httpx: route %q: %w", name, err)
+	}
+
+	var query url.Values
+	for i := 0; i < len(params); i += 2 {
+		if _, ok := used[params[i]]; ok {
+			continue
+		}
+		if query == nil {
+			query = make(url.Values)
+		}
+		query.Add(params[i], params[i+1])
+	}
+	return withQuery(path, query), nil
+}
+
+// URLFor builds the URL of the route registered under name from a request struct:
+// fields tagged `path` fill the wildcards, and non-zero fields tagged `form` become query parameters.
+// It is the inverse of the binding done by PathBinder and QueryBinder.
+// A nil req has no parameters, so it only builds the URLs of routes without wildcards.
+func URLFor[Req any](r *Router, name string, req *Req) (string, error) {
+	info, ok := r.core.route(name)
+	if !ok {
+		return "", fmt.Errorf("httpx: unknown route %q", name)
+	}
+	if req == nil {
+		req = new(Req)
+	}
+
+	meta := getStructMeta(reflect.TypeFor[Req]())
+	v := reflect.ValueOf(req).Elem()
+
+	values := make(map[string]string, len(meta.pathFields))
+	for _, f := range meta.pathFields {
+		fv, ok := urlenc.FieldByIndex(v, f.fieldIdx)
+		if !ok {
+			continue
+		}
+		strs, err := urlenc.FormatValues(fv)
+		if err != nil {
+			return "", fmt.Errorf("httpx: path field %q: %w", f.pathKey, err)
+		}
+		values[f.pathKey] = strings.Join(strs, "/")
+	}
+	path, _, err := urlenc.ExpandPath(info.Path, values)
+	if err != nil {
+		return "", fmt.Errorf("httpx: route %q: %w", name, err)
+	}
+
+	var query url.Values
+	for _, f := range meta.queryFields {
+		fv, ok := urlenc.FieldByIndex(v, f.fieldIdx)
+		if !ok || fv.IsZero() {
+			continue
+		}
+		strs, err := urlenc.FormatValues(fv)
+		if err != nil {
+			return "", fmt.Errorf("httpx: form field %q: %w", f.key, err)
+		}
+		if query == nil {
+			query = make(url.Values)
+		}
+		query[f.key] = append(query[f.key], strs...)
+	}
+	return withQuery(path, query), nil
+}
+
+func withQuery(path string, query url.Values) string {
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
@@ -0,0 +1,101 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_URL(t *testing.T) {
+	router := NewRouter()
+	noop := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	api := router.Group("/api/{tenant}")
+	api.HandleNamed("user", "GET /users/{id}", noop)
+	api.HandleNamed("file", "GET /users/{id}/files/{path...}", noop)
+	router.HandleNamed("home", "GET /{$}", noop)
+
+	tests := []struct {
+		name   string
+		route  string
+		params []string
+		want   string
+		err    string
+	}{
+		{name: "Simple", route: "user", params: []string{"tenant", "acme", "id", "42"}, want: "/api/acme/users/42"},
+		{name: "Escape", route: "user", params: []string{"tenant", "a/b", "id", "x y"}, want: "/api/a%2Fb/users/x%20y"},
+		{name: "Rest", route: "file", params: []string{"tenant", "t", "id", "1", "path", "docs/a b.txt"}, want: "/api/t/users/1/files/docs/a%20b.txt"},
+		{name: "Query", route: "user", params: []string{"tenant", "t", "id", "1", "tab", "posts", "q", "a&b"}, want: "/api/t/users/1?q=a%26b&tab=posts"},
+		{name: "Anchor", route: "home", want: "/"},
+		{name: "Missing", route: "user", params: []string{"tenant", "t"}, err: `missing path value "id"`},
+		{name: "Odd", route: "user", params: []string{"tenant"}, err: "odd number"},
+		{name: "Unknown", route: "nope", err: `unknown route "nope"`},
+		{name: "DotSegment", route: "user", params: []string{"tenant", "t", "id", ".."}, err: `path value "id" is a dot segment`},
+		{name: "RestDotSegment", route: "file", params: []string{"tenant", "t", "id", "1", "path", "../../admin"}, err: `path value "path" contains a dot segment`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := router.URL(tt.route, tt.params...)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	assert.PanicsWithValue(t, `httpx: route name "user" already used by "GET /api/{tenant}/users/{id}"`, func() {
+		router.HandleNamed("user", "GET /other", noop)
+	})
+	// 重名的路由不会被注册到 ServeMux 或路由表中
+	assert.Len(t, router.Routes(), 3)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/other", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 被 ServeMux 拒绝的模式不会占用名称
+	assert.Panics(t, func() { router.HandleNamed("dup", "GET /{$}", noop) })
+	router.HandleNamed("dup", "GET /dup", noop)
+}
+
+func TestURLFor(t *testing.T) {
+	type listReq struct {
+		Org    string   `path:"org"`
+		Page   int      `form:"page"`
+		Tags   []string `form:"tag"`
+		Filter string   `json:"filter"` // 仅 json tag 的字段不参与查询参数
+	}
+
+	router := NewRouter()
+	Register(router, "GET /orgs/{org}/repos", func(ctx context.Context, req *listReq) ([]string, error) {
+		return nil, nil
+	}, RouteName("repos"))
+
+	got, err := URLFor(router, "repos", &listReq{Org: "oy3o", Page: 2, Tags: []string{"go", "http"}, Filter: "x"})
+	require.NoError(t, err)
+	assert.Equal(t, "/orgs/oy3o/repos?page=2&tag=go&tag=http", got)
+
+	got, err = URLFor(router, "repos", &listReq{Org: "oy3o"})
+	require.NoError(t, err)
+	assert.Equal(t, "/orgs/oy3o/repos", got)
+	assert.Equal(t, "repos", router.Routes()[0].Name)
+
+	// nil 表示没有参数
+	_, err = URLFor[listReq](router, "repos", nil)
+	assert.ErrorContains(t, err, `missing path value "org"`)
+	Register(router, "GET /repos", func(ctx context.Context, req *listReq) ([]string, error) {
+		return nil, nil
+	}, RouteName("all"))
+	all, err := URLFor[listReq](router, "all", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/repos", all)
+
+	// 生成的 URL 可以被同一路由正确绑定
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", got, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
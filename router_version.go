@@ -0,0 +1,156 @@
+package httpx
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CodeUnsupportedVersion 请求的 API 版本不存在 (406)
+const CodeUnsupportedVersion = "UNSUPPORTED_VERSION"
+
+// ErrUnsupportedVersion is returned when the requested API version is not registered.
+var ErrUnsupportedVersion = &HttpError{HttpCode: http.StatusNotAcceptable, BizCode: CodeUnsupportedVersion, Msg: "Unsupported API Version"}
+
+// VersionOptions configures the version negotiation of Router.Versions.
+type VersionOptions struct {
+	// Header is the request header carrying the version. It is also set on the response
+	// to report the version served. Default: "API-Version".
+	Header string
+
+	// AcceptParam is the media type parameter of the Accept header carrying the version,
+	// e.g. "Accept: application/json; version=2". Default: "version".
+	AcceptParam string
+
+	// Default is the version served when the request doesn't ask for one.
+	// Default: the version added last.
+	Default string
+}
+
+// VersionSet dispatches the requests under a prefix to one Router per API version.
+// The version is read from the Header first, then from the AcceptParam of the Accept header.
+type VersionSet struct {
+	parent *Router
+	prefix string
+	opts   VersionOptions
+
+	mu       sync.RWMutex
+	versions map[string]*versionEntry
+	latest   string
+}
+
+type versionEntry struct {
+	router  *Router
+	handler http.Handler
+}
+
+// Versions registers a version dispatcher for the given prefix pattern (see Group for its syntax)
+// and returns the VersionSet used to add the versions.
+//
+//	vs := r.Versions("/api", httpx.VersionOptions{Default: "2"})
+//	vs.Version("1", httpx.DeprecationOptions{Sunset: sunset}).Handle("GET /users", usersV1)
+//	vs.Version("2").Handle("GET /users", usersV2)
+//
+// Requests asking for an unknown version are rejected with ErrUnsupportedVersion.
+func (r *Router) Versions(prefix string, opts ...VersionOptions) *VersionSet {
+	var opt VersionOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Header == "" {
+		opt.Header = "API-Version"
+	}
+	opt.Header = http.CanonicalHeaderKey(opt.Header)
+	if opt.AcceptParam == "" {
+		opt.AcceptParam = "version"
+	}
+
+	method, host, path := splitPattern(prefix)
+	vs := &VersionSet{
+		parent:   r.Group(joinPattern(method, host, path)),
+		opts:     opt,
+		versions: make(map[string]*versionEntry),
+	}
+	vs.prefix = vs.parent.prefix
+
+	// The versions are not known yet, so the whole subtree is dispatched.
+	vs.parent.handle(joinPattern(vs.parent.method, vs.parent.host, vs.prefix+"/"), vs)
+	return vs
+}
+
+// Version adds a version and returns the Router to register its routes on.
+// Routes use the same patterns as the other versions, relative to the VersionSet prefix.
+// A deprecated version is served through the Deprecate middleware.
+func (vs *VersionSet) Version(name string, deprecation ...DeprecationOptions) *Router {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if _, ok := vs.versions[name]; ok {
+		panic("httpx: API version " + strconv.Quote(name) + " already added")
+	}
+
+	entry := &versionEntry{
+		router: &Router{
+			mux:     http.NewServeMux(),
+			core:    vs.parent.core,
+			method:  vs.parent.method,
+			host:    vs.parent.host,
+			prefix:  vs.prefix,
+			version: name,
+		},
+	}
+	entry.handler = entry.router
+	if len(deprecation) > 0 {
+		entry.handler = Deprecate(deprecation[0])(entry.router)
+	}
+	vs.versions[name] = entry
+	vs.latest = name
+	return entry.router
+}
+
+// ServeHTTP satisfies http.Handler.
+func (vs *VersionSet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	version := vs.requested(r)
+
+	vs.mu.RLock()
+	if version == "" {
+		version = vs.opts.Default
+		if version == "" {
+			version = vs.latest
+		}
+	}
+	entry, ok := vs.versions[version]
+	vs.mu.RUnlock()
+
+	h := w.Header()
+	h.Add("Vary", vs.opts.Header)
+	h.Add("Vary", "Accept")
+	if !ok {
+		Error(w, r, ErrUnsupportedVersion)
+		return
+	}
+
+	h[vs.opts.Header] = []string{version}
+	entry.handler.ServeHTTP(w, r)
+}
+
+// requested returns the version asked for by the request, or "" if none.
+func (vs *VersionSet) requested(r *http.Request) string {
+	if v := r.Header[vs.opts.Header]; len(v) > 0 && v[0] != "" {
+		return strings.TrimSpace(v[0])
+	}
+	for _, accept := range r.Header["Accept"] {
+		for mediaRange := range strings.SplitSeq(accept, ",") {
+			if !strings.Contains(mediaRange, vs.opts.AcceptParam) {
+				continue
+			}
+			if _, params, err := mime.ParseMediaType(mediaRange); err == nil {
+				if v := params[vs.opts.AcceptParam]; v != "" {
+					return v
+				}
+			}
+		}
+	}
+	return ""
+}
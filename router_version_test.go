@@ -0,0 +1,99 @@
+package httpx
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Host(t *testing.T) {
+	router := NewRouter()
+	router.Host("api.example.com").HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "api:", r.PathValue("id"))
+	})
+	router.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "default:", r.PathValue("id"))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "http://api.example.com/users/1", nil))
+	assert.Equal(t, "api:1", w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "http://www.example.com/users/2", nil))
+	assert.Equal(t, "default:2", w.Body.String())
+
+	assert.Equal(t, "api.example.com", router.Routes()[1].Host)
+	assert.Panics(t, func() { router.Host("/api") })
+}
+
+func TestRouter_Versions(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	router := NewRouter()
+	vs := router.Versions("/api", VersionOptions{Default: "2"})
+	v1 := vs.Version("1", DeprecationOptions{Since: since, Sunset: sunset, Link: "https://example.com/migrate"})
+	v1.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "v1") })
+	v2 := vs.Version("2")
+	v2.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "v2") })
+	vs.Version("3-beta").HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "v3:", r.PathValue("id"))
+	})
+
+	serve := func(target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		w := serve("/api/users", nil)
+		assert.Equal(t, "v2", w.Body.String())
+		assert.Equal(t, "2", w.Header().Get("API-Version"))
+		assert.Equal(t, []string{"Api-Version", "Accept"}, w.Header().Values("Vary"))
+		assert.Empty(t, w.Header().Get("Deprecation"))
+	})
+
+	t.Run("Header", func(t *testing.T) {
+		w := serve("/api/users/7", http.Header{"Api-Version": {"3-beta"}})
+		assert.Equal(t, "v3:7", w.Body.String())
+	})
+
+	t.Run("AcceptParam", func(t *testing.T) {
+		w := serve("/api/users", http.Header{"Accept": {"text/html, application/json; version=1"}})
+		assert.Equal(t, "v1", w.Body.String())
+		assert.Equal(t, "@1704067200", w.Header().Get("Deprecation"))
+		assert.Equal(t, "Mon, 30 Jun 2025 00:00:00 GMT", w.Header().Get("Sunset"))
+		assert.Equal(t, `<https://example.com/migrate>; rel="deprecation"`, w.Header().Get("Link"))
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		w := serve("/api/users", http.Header{"Api-Version": {"9"}})
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+		assert.Contains(t, w.Body.String(), CodeUnsupportedVersion)
+	})
+
+	t.Run("NotFoundInVersion", func(t *testing.T) {
+		w := serve("/api/users/1", http.Header{"Api-Version": {"1"}})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), CodeNotFound)
+	})
+
+	routes := router.Routes()
+	require.Len(t, routes, 3)
+	assert.Equal(t, "1", routes[0].Version)
+	assert.Equal(t, "GET /api/users", routes[1].Pattern)
+	assert.Equal(t, "2", routes[1].Version)
+
+	assert.Panics(t, func() { vs.Version("2") })
+}
@@ -0,0 +1,339 @@
+package httpx
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// 本文件实现了 RFC 8941 (Structured Field Values) 中 HTTP Message Signatures
+// 与 Content-Digest 所需的子集：Dictionary、Inner List、Parameters，以及
+// Integer、String、Token、Byte Sequence、Boolean 这几种 Bare Item (不支持 Decimal)。
+
+var errStructuredField = errors.New("httpx: malformed structured field")
+
+// sfToken 区分 Token 与 String 两种 Bare Item
+type sfToken string
+
+type sfParam struct {
+	key   string
+	value any // int64 | string | sfToken | []byte | bool
+}
+
+type sfParams []sfParam
+
+func (ps sfParams) get(key string) (any, bool) {
+	for _, p := range ps {
+		if p.key == key {
+			return p.value, true
+		}
+	}
+	return nil, false
+}
+
+func (ps sfParams) str(key string) string {
+	v, _ := ps.get(key)
+	s, _ := v.(string)
+	return s
+}
+
+type sfItem struct {
+	value  any
+	params sfParams
+}
+
+// sfMember 是 Dictionary 的一个成员，值为 Item 或 Inner List (inner != nil)
+type sfMember struct {
+	name   string
+	item   sfItem
+	inner  []sfItem
+	params sfParams // Inner List 的参数
+}
+
+type sfParser struct {
+	s string
+	i int
+}
+
+func (p *sfParser) eof() bool { return p.i >= len(p.s) }
+
+func (p *sfParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.i]
+}
+
+func (p *sfParser) skipSP() {
+	for !p.eof() && p.s[p.i] == ' ' {
+		p.i++
+	}
+}
+
+func (p *sfParser) skipOWS() {
+	for !p.eof() && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+// parseSFDictionary 解析一个 Dictionary。多行同名请求头应先以 ", " 连接。
+func parseSFDictionary(s string) ([]sfMember, error) {
+	p := &sfParser{s: strings.TrimSpace(s)}
+	var members []sfMember
+	for !p.eof() {
+		name, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		m := sfMember{name: name, item: sfItem{value: true}}
+		if p.peek() == '=' {
+			p.i++
+			if p.peek() == '(' {
+				if m.inner, err = p.innerList(); err != nil {
+					return nil, err
+				}
+				if m.inner == nil {
+					m.inner = []sfItem{}
+				}
+			} else if m.item.value, err = p.bareItem(); err != nil {
+				return nil, err
+			}
+		}
+		params, err := p.params()
+		if err != nil {
+			return nil, err
+		}
+		if m.inner != nil {
+			m.params = params
+		} else {
+			m.item.params = params
+		}
+		members = append(members, m)
+
+		p.skipOWS()
+		if p.eof() {
+			break
+		}
+		if p.peek() != ',' {
+			return nil, errStructuredField
+		}
+		p.i++
+		p.skipOWS()
+		if p.eof() {
+			return nil, errStructuredField // 不允许尾随逗号
+		}
+	}
+	return members, nil
+}
+
+// parseSFItem 解析单个带参数的 Item，如 `"@query-param";name="id"`。
+func parseSFItem(s string) (sfItem, error) {
+	p := &sfParser{s: strings.TrimSpace(s)}
+	v, err := p.bareItem()
+	if err != nil {
+		return sfItem{}, err
+	}
+	params, err := p.params()
+	if err != nil {
+		return sfItem{}, err
+	}
+	if !p.eof() {
+		return sfItem{}, errStructuredField
+	}
+	return sfItem{value: v, params: params}, nil
+}
+
+func (p *sfParser) innerList() ([]sfItem, error) {
+	p.i++ // '('
+	var items []sfItem
+	for {
+		p.skipSP()
+		if p.peek() == ')' {
+			p.i++
+			return items, nil
+		}
+		v, err := p.bareItem()
+		if err != nil {
+			return nil, err
+		}
+		params, err := p.params()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, sfItem{value: v, params: params})
+		if c := p.peek(); c != ' ' && c != ')' {
+			return nil, errStructuredField
+		}
+	}
+}
+
+func (p *sfParser) params() (sfParams, error) {
+	var params sfParams
+	for p.peek() == ';' {
+		p.i++
+		p.skipSP()
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		var v any = true
+		if p.peek() == '=' {
+			p.i++
+			if v, err = p.bareItem(); err != nil {
+				return nil, err
+			}
+		}
+		params = append(params, sfParam{key: key, value: v})
+	}
+	return params, nil
+}
+
+func (p *sfParser) key() (string, error) {
+	start := p.i
+	if c := p.peek(); !(c >= 'a' && c <= 'z') && c != '*' {
+		return "", errStructuredField
+	}
+	for !p.eof() {
+		c := p.s[p.i]
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_' || c == '-' || c == '.' || c == '*' {
+			p.i++
+			continue
+		}
+		break
+	}
+	return p.s[start:p.i], nil
+}
+
+func (p *sfParser) bareItem() (any, error) {
+	c := p.peek()
+	switch {
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.i
+		p.i++
+		for !p.eof() && p.s[p.i] >= '0' && p.s[p.i] <= '9' {
+			p.i++
+		}
+		if p.peek() == '.' || p.i-start > 16 {
+			return nil, errStructuredField
+		}
+		n, err := strconv.ParseInt(p.s[start:p.i], 10, 64)
+		if err != nil {
+			return nil, errStructuredField
+		}
+		return n, nil
+
+	case c == '"':
+		p.i++
+		var sb strings.Builder
+		for !p.eof() {
+			c := p.s[p.i]
+			p.i++
+			switch {
+			case c == '\\':
+				if p.eof() || (p.s[p.i] != '"' && p.s[p.i] != '\\') {
+					return nil, errStructuredField
+				}
+				sb.WriteByte(p.s[p.i])
+				p.i++
+			case c == '"':
+				return sb.String(), nil
+			case c < 0x20 || c > 0x7e:
+				return nil, errStructuredField
+			default:
+				sb.WriteByte(c)
+			}
+		}
+		return nil, errStructuredField
+
+	case c == ':':
+		end := strings.IndexByte(p.s[p.i+1:], ':')
+		if end < 0 {
+			return nil, errStructuredField
+		}
+		b, err := base64.StdEncoding.DecodeString(p.s[p.i+1 : p.i+1+end])
+		if err != nil {
+			return nil, errStructuredField
+		}
+		p.i += end + 2
+		return b, nil
+
+	case c == '?':
+		if p.i+1 >= len(p.s) || (p.s[p.i+1] != '0' && p.s[p.i+1] != '1') {
+			return nil, errStructuredField
+		}
+		p.i += 2
+		return p.s[p.i-1] == '1', nil
+
+	case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '*':
+		start := p.i
+		for !p.eof() && (isAlphaNum(p.s[p.i]) || strings.IndexByte(":/!#$%&'*+-.^_`|~", p.s[p.i]) >= 0) {
+			p.i++
+		}
+		return sfToken(p.s[start:p.i]), nil
+	}
+	return nil, errStructuredField
+}
+
+func isAlphaNum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// 序列化
+
+func writeSFBareItem(sb *strings.Builder, v any) {
+	switch v := v.(type) {
+	case int64:
+		sb.WriteString(strconv.FormatInt(v, 10))
+	case string:
+		sb.WriteByte('"')
+		for i := 0; i < len(v); i++ {
+			if v[i] == '"' || v[i] == '\\' {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(v[i])
+		}
+		sb.WriteByte('"')
+	case sfToken:
+		sb.WriteString(string(v))
+	case []byte:
+		sb.WriteByte(':')
+		sb.WriteString(base64.StdEncoding.EncodeToString(v))
+		sb.WriteByte(':')
+	case bool:
+		if v {
+			sb.WriteString("?1")
+		} else {
+			sb.WriteString("?0")
+		}
+	}
+}
+
+func writeSFParams(sb *strings.Builder, params sfParams) {
+	for _, p := range params {
+		sb.WriteByte(';')
+		sb.WriteString(p.key)
+		if v, ok := p.value.(bool); ok && v {
+			continue
+		}
+		sb.WriteByte('=')
+		writeSFBareItem(sb, p.value)
+	}
+}
+
+func writeSFItem(sb *strings.Builder, item sfItem) {
+	writeSFBareItem(sb, item.value)
+	writeSFParams(sb, item.params)
+}
+
+func writeSFInnerList(sb *strings.Builder, items []sfItem, params sfParams) {
+	sb.WriteByte('(')
+	for i, item := range items {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		writeSFItem(sb, item)
+	}
+	sb.WriteByte(')')
+	writeSFParams(sb, params)
+}
@@ -0,0 +1,57 @@
+package httpx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSFDictionary(t *testing.T) {
+	members, err := parseSFDictionary(`sig1=("@method" "@query-param";name="id" "content-digest");created=1618884473;keyid="k\"1";alg=tok, sig2=:AQID:, flag, n=-42;a=?0`)
+	require.NoError(t, err)
+	require.Len(t, members, 4)
+
+	sig1 := members[0]
+	assert.Equal(t, "sig1", sig1.name)
+	require.Len(t, sig1.inner, 3)
+	assert.Equal(t, "@query-param", sig1.inner[1].value)
+	assert.Equal(t, "id", sig1.inner[1].params.str("name"))
+	created, _ := sig1.params.get("created")
+	assert.Equal(t, int64(1618884473), created)
+	assert.Equal(t, `k"1`, sig1.params.str("keyid"))
+	alg, _ := sig1.params.get("alg")
+	assert.Equal(t, sfToken("tok"), alg)
+
+	assert.Equal(t, []byte{1, 2, 3}, members[1].item.value)
+	assert.Equal(t, true, members[2].item.value)
+	assert.Equal(t, int64(-42), members[3].item.value)
+	a, _ := members[3].item.params.get("a")
+	assert.Equal(t, false, a)
+
+	// 序列化后与规范形式一致
+	var sb strings.Builder
+	writeSFInnerList(&sb, sig1.inner, sig1.params)
+	assert.Equal(t, `("@method" "@query-param";name="id" "content-digest");created=1618884473;keyid="k\"1";alg=tok`, sb.String())
+
+	empty, err := parseSFDictionary(`sig=();created=1`)
+	require.NoError(t, err)
+	assert.NotNil(t, empty[0].inner)
+}
+
+func TestParseSFDictionary_Invalid(t *testing.T) {
+	for _, s := range []string{
+		`Sig=1`,           // key 必须小写
+		`a=1,`,            // 尾随逗号
+		`a=1.5`,           // 不支持 Decimal
+		`a="unterminated`, // 未闭合的字符串
+		`a=:not base64!:`, // 非法 Byte Sequence
+		`a=("x""y")`,      // Inner List 成员之间缺少空格
+		`a=1 b=2`,         // 缺少逗号
+		`a=?2`,            // 非法 Boolean
+	} {
+		_, err := parseSFDictionary(s)
+		assert.Error(t, err, s)
+	}
+}